@@ -7,6 +7,8 @@ require (
 	github.com/mkideal/cli v0.2.7
 	github.com/mkideal/pkg v0.1.3
 	github.com/stretchr/testify v1.5.1
+	golang.org/x/sys v0.1.0
+	gopkg.in/yaml.v2 v2.2.2
 )
 
 require (
@@ -17,7 +19,5 @@ require (
 	github.com/mkideal/expr v0.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/crypto v0.1.0 // indirect
-	golang.org/x/sys v0.1.0 // indirect
 	golang.org/x/term v0.1.0 // indirect
-	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
@@ -7,16 +7,10 @@ echo "Installing compiler: midc"
 go install
 cd ../../..
 
-languages=`cat languages.txt`
-
-for lang in $languages
-do
-	_pwd=`pwd`
-	cd ./src/cmd/mid-gen-$lang
-	echo "Installing generator: mid-gen-$lang"
-	go install
-	cd $_pwd
-done
+cd ./src/cmd/mid-gen
+echo "Installing generator: mid-gen"
+go install
+cd ../../..
 
 echo "Coping config file"
 cp ./midconfig $HOME/.midconfig
@@ -4,7 +4,6 @@ set -e
 
 CMD_GO=go
 RELEASE_DIR=targets
-LANGUAGES=`cat languages.txt`
 VERSION=`cat VERSION`
 
 cd ./hack
@@ -28,14 +27,9 @@ function release_mid_for() {
 	echo "GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/midc$_suffix ./src/cmd/midc/"
 	GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/midc$_suffix ./src/cmd/midc/
 
-	# Building generators
-	local _lang
-	for _lang in $LANGUAGES
-	do
-		local _bin=mid-gen-$_lang
-		echo "GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/$_bin$_suffix ./src/cmd/$_bin"
-		GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/$_bin$_suffix ./src/cmd/$_bin
-	done
+	# Building generator `mid-gen`
+	echo "GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/mid-gen$_suffix ./src/cmd/mid-gen/"
+	GOOS=$_os GOARCH=$_arch $CMD_GO build -o $_target_dir/bin/mid-gen$_suffix ./src/cmd/mid-gen/
 
 	# Coping files
 	cp ./midconfig $_target_dir/
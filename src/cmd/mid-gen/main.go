@@ -0,0 +1,21 @@
+// mid-gen is the generator plugin of midc, it generates codes by templates
+// for languages of builtin backends: go, cpp, csharp, js, ts and protobuf.
+//
+// A third-party generator could be built by registering its own backend and
+// calling genutil.Main, then passed to midc by flag `-P<lang>=<binary>`.
+package main
+
+import (
+	"github.com/midlang/mid/src/genutil"
+
+	_ "github.com/midlang/mid/src/genutil/backends/cpp"
+	_ "github.com/midlang/mid/src/genutil/backends/csharp"
+	_ "github.com/midlang/mid/src/genutil/backends/golang"
+	_ "github.com/midlang/mid/src/genutil/backends/js"
+	_ "github.com/midlang/mid/src/genutil/backends/protobuf"
+	_ "github.com/midlang/mid/src/genutil/backends/ts"
+)
+
+func main() {
+	genutil.Main()
+}
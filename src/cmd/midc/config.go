@@ -12,6 +12,9 @@ type Config struct {
 	Suffix  string `json:"suffix" cli:"suffix" usage:"source file suffix" dft:".mid" name:"SUFFIX"`
 	MidRoot string `json:"midroot" cli:"midroot" dft:"$MIDROOT" usage:"mid root directory"`
 
+	// Types overrides type mappings for each language, e.g. {"csharp": {"int": "int32"}}
+	Types map[string]map[string]string `json:"types" cli:"-"`
+
 	Plugins       *build.PluginSet `json:"-" cli:"-"`
 	LoadedPlugins []build.Plugin   `json:"plugins" cli:"-"`
 }
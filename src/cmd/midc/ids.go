@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/gopherd/log"
+	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
+	"github.com/midlang/mid/src/mid/parser"
+	"github.com/mkideal/cli"
+)
+
+type idsArgT struct {
+	cli.Helper
+	LogLevel    log.Level         `cli:"log" usage:"log level for debugging: trace/debug/info/warn/error/fatal" dft:"warn"`
+	Suffix      string            `cli:"suffix" usage:"source file suffix" dft:".mid"`
+	Envvars     map[string]string `cli:"E,env" usage:"custom defined environment variables, also referenced by $NAME in source files"`
+	ImportPaths []string          `cli:"I,importpath" usage:"import paths for lookuping imports"`
+	IdAllocator string            `cli:"*id-allocator" usage:"id allocator name and options, the same as midc"`
+	IdFor       string            `cli:"*id-for" usage:"specific bean kinds which should be allocated a id"`
+}
+
+func newIdsArgT() interface{} {
+	return &idsArgT{
+		Envvars: map[string]string{},
+	}
+}
+
+var idsCommand = &cli.Command{
+	Name: "ids",
+	Desc: "manage ids recorded by id allocator",
+	Fn: func(ctx *cli.Context) error {
+		ctx.String(ctx.Command().Usage(ctx))
+		return nil
+	},
+}
+
+var idsListCommand = &cli.Command{
+	Name: "list",
+	Desc: "list recorded ids and their states: active, pinned, unused, retired or missing",
+	Argv: newIdsArgT,
+	Fn: func(ctx *cli.Context) error {
+		state, err := loadIdState(ctx)
+		if err != nil {
+			return err
+		}
+		defer state.file.Close()
+
+		type row struct {
+			id         int
+			key, state string
+		}
+		var rows []row
+		build.SortIdPairs(state.file.Pairs)
+		for _, pair := range state.file.Pairs {
+			r := row{id: pair.Id, key: pair.Key, state: "unused"}
+			if build.IsRetiredKey(pair.Key) {
+				r.state = "retired"
+			} else if entry, ok := state.entries[pair.Key]; ok {
+				r.state = "active"
+				if _, pinned, _ := entry.PinnedId(); pinned {
+					r.state = "pinned"
+				}
+			}
+			rows = append(rows, r)
+		}
+		for _, entry := range state.list {
+			if _, ok := state.recorded[entry.Key]; !ok {
+				rows = append(rows, row{key: entry.Key, state: "missing"})
+			}
+		}
+		w := tabwriter.NewWriter(ctx, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tKEY\tSTATE")
+		for _, r := range rows {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", r.id, r.key, r.state)
+		}
+		return w.Flush()
+	},
+}
+
+var idsCheckCommand = &cli.Command{
+	Name: "check",
+	Desc: "check recorded ids for collisions, duplicated keys, ids out of range and conflicts with pinned ids",
+	Argv: newIdsArgT,
+	Fn: func(ctx *cli.Context) error {
+		state, err := loadIdState(ctx)
+		if err != nil {
+			return err
+		}
+		defer state.file.Close()
+
+		var problems, notes []string
+		type slot struct {
+			scope string
+			id    int
+		}
+		owners := make(map[slot]string)
+		keys := make(map[string]int)
+		for _, pair := range state.file.Pairs {
+			if id, ok := keys[pair.Key]; ok {
+				problems = append(problems, fmt.Sprintf("key %s is recorded with both ids %d and %d", pair.Key, id, pair.Id))
+			} else {
+				keys[pair.Key] = pair.Id
+			}
+			s := slot{build.IdScope(pair.Key), pair.Id}
+			if owner, ok := owners[s]; ok {
+				problems = append(problems, fmt.Sprintf("id %d is used by both %s and %s", pair.Id, owner, pair.Key))
+			} else {
+				owners[s] = pair.Key
+			}
+			if build.IsRetiredKey(pair.Key) {
+				continue
+			}
+			entry, ok := state.entries[pair.Key]
+			if !ok {
+				notes = append(notes, fmt.Sprintf("%s=%d is unused, retire it by `midc ids retire`", pair.Key, pair.Id))
+				continue
+			}
+			// ranges of pinned ids are checked with tags below
+			if _, pinned, _ := entry.PinnedId(); pinned {
+				continue
+			}
+			if r, ok := state.options.Ranges.Allows(entry.Kind, entry.Key, pair.Id); !ok {
+				problems = append(problems, fmt.Sprintf("id %d of %s %s out of range %v", pair.Id, entry.Kind, pair.Key, r))
+			}
+		}
+		pinnedBy := make(map[slot]string)
+		for _, entry := range state.list {
+			id, pinned, err := entry.PinnedId()
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if !pinned {
+				continue
+			}
+			pos := entry.Position()
+			if id <= 0 {
+				problems = append(problems, fmt.Sprintf("%v: pinned id %d of %s is not positive", pos, id, entry.Key))
+				continue
+			}
+			s := slot{build.IdScope(entry.Key), id}
+			if other, ok := pinnedBy[s]; ok {
+				problems = append(problems, fmt.Sprintf("%v: pinned id %d of %s conflicts with %s", pos, id, entry.Key, other))
+				continue
+			}
+			pinnedBy[s] = entry.Key
+			if r, ok := state.options.Ranges.Allows(entry.Kind, entry.Key, id); !ok {
+				problems = append(problems, fmt.Sprintf("%v: pinned id %d of %s %s out of range %v", pos, id, entry.Kind, entry.Key, r))
+			}
+			if owner, ok := owners[s]; ok && owner != entry.Key {
+				problems = append(problems, fmt.Sprintf("%v: pinned id %d of %s is recorded for %s", pos, id, entry.Key, owner))
+			} else if recorded, ok := state.recorded[entry.Key]; ok && recorded != id {
+				notes = append(notes, fmt.Sprintf("%v: id of %s recorded as %d would be replaced by pinned id %d", pos, entry.Key, recorded, id))
+			}
+		}
+		for _, note := range notes {
+			ctx.String("note: %s\n", note)
+		}
+		for _, problem := range problems {
+			ctx.String("%s: %s\n", ctx.Color().Red("error"), problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problem(s) found in %s", len(problems), state.file.Filename)
+		}
+		return nil
+	},
+}
+
+var idsRetireCommand = &cli.Command{
+	Name: "retire",
+	Desc: "retire ids of deleted beans, retired ids are kept in file and never allocated again",
+	Argv: newIdsArgT,
+	Fn: func(ctx *cli.Context) error {
+		state, err := loadIdState(ctx)
+		if err != nil {
+			return err
+		}
+		defer state.file.Close()
+
+		retired := 0
+		for i, pair := range state.file.Pairs {
+			if build.IsRetiredKey(pair.Key) {
+				continue
+			}
+			if _, ok := state.entries[pair.Key]; ok {
+				continue
+			}
+			ctx.String("retire %s=%d\n", pair.Key, pair.Id)
+			state.file.Pairs[i].Key = build.RetiredKey(pair.Key, pair.Id)
+			retired++
+		}
+		if retired == 0 {
+			return nil
+		}
+		return state.file.Write()
+	},
+}
+
+// idState holds recorded ids and beans which should be allocated ids
+type idState struct {
+	options  build.IdAllocatorOptions
+	file     *build.IdFile
+	list     []build.IdEntry
+	entries  map[string]build.IdEntry
+	recorded map[string]int
+}
+
+// loadIdState builds sources and opens the id file of allocator, file should be closed by caller
+func loadIdState(ctx *cli.Context) (*idState, error) {
+	argv := ctx.Argv().(*idsArgT)
+	log.SetLevel(argv.LogLevel)
+
+	name, opts := parseIdAllocator(argv.IdAllocator)
+	options, err := build.ParseIdAllocatorOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if options.Filename == "" {
+		return nil, errors.New("id allocator " + name + " has no file")
+	}
+	inputs, err := sourceFiles(ctx, ctx.Args(), argv.Suffix)
+	if err != nil {
+		return nil, err
+	}
+	fset := lexer.NewFileSet()
+	pkgs, err := parser.ParseFilesWithEnv(fset, parser.Env(argv.Envvars), argv.ImportPaths, inputs)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := build.Build(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	state := &idState{
+		options:  options,
+		list:     build.IdEntries(builder, parseIdFor(argv.IdFor)),
+		entries:  make(map[string]build.IdEntry),
+		recorded: make(map[string]int),
+	}
+	for _, entry := range state.list {
+		state.entries[entry.Key] = entry
+	}
+	state.file, err = build.OpenIdFile(options.Filename, options.Format)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range state.file.Pairs {
+		if !build.IsRetiredKey(pair.Key) {
+			state.recorded[pair.Key] = pair.Id
+		}
+	}
+	return state, nil
+}
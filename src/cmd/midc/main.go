@@ -21,12 +21,12 @@ type argT struct {
 	LogLevel     log.Level         `cli:"log" usage:"log level for debugging: trace/debug/info/warn/error/fatal" dft:"warn"`
 	Outdirs      map[string]string `cli:"O,outdir" usage:"output directories for each language, e.g. -Ogo=dir1 -Ocpp=dir2"`
 	Extensions   []string          `cli:"X,extension" usage:"extensions, e.g. -Xmeta -Xcodec"`
-	Envvars      map[string]string `cli:"E,env" usage:"custom defined environment variables"`
+	Envvars      map[string]string `cli:"E,env" usage:"custom defined environment variables, also referenced by $NAME in source files"`
 	ImportPaths  []string          `cli:"I,importpath" usage:"import paths for lookuping imports"`
 	TemplateKind string            `cli:"K,tempkind" usage:"template kind, a directory name" dft:"default"`
 	TemplatesDir map[string]string `cli:"T,template" usage:"templates directories for each language, e.g. -Tgo=dir1 -Tjava=dir2"`
 	PluginFiles  map[string]string `cli:"P" usage:"plugin generator file"`
-	IdAllocator  string            `cli:"id-allocator" usage:"id allocator name and options,supported allocators: file"`
+	IdAllocator  string            `cli:"id-allocator" usage:"id allocator name and options,supported allocators: file,sequential,hash"`
 	IdFor        string            `cli:"id-for" usage:"specific bean kinds which should be allocated a id"`
 
 	Inputs []string `cli:"-"`
@@ -72,9 +72,6 @@ var root = &cli.Command{
 			formatPlugin = func(lang, name string) string {
 				return "<" + blue(lang) + ":" + cyan(name) + ">"
 			}
-			sourceFileFilter = func(finfo os.FileInfo) bool {
-				return strings.HasSuffix(finfo.Name(), argv.Suffix)
-			}
 		)
 
 		// load config file
@@ -133,31 +130,9 @@ var root = &cli.Command{
 
 		// validate source directories and files
 		argv.Inputs = ctx.Args()
-		if len(argv.Inputs) == 0 {
-			argv.Inputs = []string{"."}
-		}
-		for _, in := range argv.Inputs {
-			finfo, err := os.Lstat(in)
-			if err != nil {
-				log.Error().
-					String("input", cyan(in)).
-					String("error", red(err)).
-					Print("stat error")
-				return nil
-			}
-			if finfo.IsDir() {
-				files, err := filesInDir(in, sourceFileFilter)
-				if err != nil {
-					log.Error().
-						String("input", cyan(in)).
-						String("error", red(err)).
-						Print("get source files from dir error")
-					return nil
-				}
-				inputs = append(inputs, files...)
-			} else {
-				inputs = append(inputs, in)
-			}
+		inputs, err = sourceFiles(ctx, argv.Inputs, argv.Suffix)
+		if err != nil {
+			return nil
 		}
 
 		// lookup plugins
@@ -217,8 +192,10 @@ var root = &cli.Command{
 			// initialize RuntimeConfig for plugin
 			plugin.RuntimeConfig.Outdir = outdir
 			plugin.RuntimeConfig.ExtentionsDir = extensionsDir
+			plugin.RuntimeConfig.TemplatesRootDir = filepath.Join(argv.MidRoot, "templates")
 			plugin.RuntimeConfig.Extensions = extensions
 			plugin.RuntimeConfig.Envvars = argv.Envvars
+			plugin.RuntimeConfig.Types = argv.Types[plugin.Lang]
 			plugin.RuntimeConfig.Verbose = argv.LogLevel.String()
 			if templatesDir, ok := argv.TemplatesDir[plugin.Lang]; ok {
 				// replace default templatesDir
@@ -226,8 +203,7 @@ var root = &cli.Command{
 			}
 			if plugin.TemplatesDir == "" {
 				// if templatesDir is empty
-				templatesRootDir := filepath.Join(argv.MidRoot, "templates")
-				fullpath := filepath.Join(templatesRootDir, argv.TemplateKind, plugin.Lang)
+				fullpath := filepath.Join(plugin.RuntimeConfig.TemplatesRootDir, argv.TemplateKind, plugin.Lang)
 				plugin.TemplatesDir, err = filepath.Abs(fullpath)
 				if err != nil {
 					log.Error().
@@ -252,7 +228,7 @@ var root = &cli.Command{
 
 		// build source
 		fset := lexer.NewFileSet()
-		pkgs, err := parser.ParseFiles(fset, argv.ImportPaths, inputs)
+		pkgs, err := parser.ParseFilesWithEnv(fset, parser.Env(argv.Envvars), argv.ImportPaths, inputs)
 		if err != nil {
 			log.Error().
 				String("error", red(err)).
@@ -268,13 +244,8 @@ var root = &cli.Command{
 		}
 
 		// allocate id for beans which kind contained in argv.IdFor
-		allocatorInfos := strings.SplitN(argv.IdAllocator, ":", 2)
-		if argv.IdAllocator != "" && len(allocatorInfos) != 0 {
-			allocatorName := allocatorInfos[0]
-			allocatorOpts := ""
-			if len(allocatorInfos) == 2 {
-				allocatorOpts = allocatorInfos[1]
-			}
+		if argv.IdAllocator != "" {
+			allocatorName, allocatorOpts := parseIdAllocator(argv.IdAllocator)
 			allocator, err := build.NewBeanIdAllocator(allocatorName, allocatorOpts)
 			if err != nil {
 				log.Error().
@@ -282,18 +253,12 @@ var root = &cli.Command{
 					Print("new bean id allocator error")
 				return err
 			}
-			idFor := make(map[string]bool)
-			for _, f := range strings.Split(argv.IdFor, ",") {
-				idFor[strings.TrimSpace(f)] = true
-			}
-			for _, pkg := range builder.Packages {
-				for _, file := range pkg.Files {
-					for _, bean := range file.Beans {
-						if idFor[bean.Kind] {
-							bean.Id = allocator.Allocate(build.JoinBeanKey(pkg.Name, bean.Name))
-						}
-					}
-				}
+			defer allocator.Close()
+			if err := build.AllocateIds(allocator, builder, parseIdFor(argv.IdFor)); err != nil {
+				log.Error().
+					String("error", red(err)).
+					Print("allocate id error")
+				return err
 			}
 			if err := allocator.Output(nil); err != nil {
 				log.Error().
@@ -302,6 +267,12 @@ var root = &cli.Command{
 				return err
 			}
 		}
+		if err := build.CheckEnumValues(builder); err != nil {
+			log.Error().
+				String("error", red(err)).
+				Print("check enum values error")
+			return err
+		}
 
 		// generate codes
 		for _, plugin := range plugins {
@@ -320,10 +291,77 @@ var root = &cli.Command{
 }
 
 func main() {
-	err := root.Run(os.Args[1:])
-	log.If(err != nil).Error().
-		Error("error", err).
-		Print("run error")
+	err := cli.Root(root,
+		cli.Tree(idsCommand,
+			cli.Tree(idsListCommand),
+			cli.Tree(idsCheckCommand),
+			cli.Tree(idsRetireCommand),
+		),
+	).Run(os.Args[1:])
+	if err != nil {
+		log.Error().
+			Error("error", err).
+			Print("run error")
+		os.Exit(1)
+	}
+}
+
+// sourceFiles returns source files of inputs, files in directory are filtered by suffix
+func sourceFiles(ctx *cli.Context, inputs []string, suffix string) ([]string, error) {
+	var (
+		cyan  = ctx.Color().Cyan
+		red   = ctx.Color().Red
+		files []string
+	)
+	if len(inputs) == 0 {
+		inputs = []string{"."}
+	}
+	filter := func(finfo os.FileInfo) bool {
+		return strings.HasSuffix(finfo.Name(), suffix)
+	}
+	for _, in := range inputs {
+		finfo, err := os.Lstat(in)
+		if err != nil {
+			log.Error().
+				String("input", cyan(in)).
+				String("error", red(err)).
+				Print("stat error")
+			return nil, err
+		}
+		if finfo.IsDir() {
+			list, err := filesInDir(in, filter)
+			if err != nil {
+				log.Error().
+					String("input", cyan(in)).
+					String("error", red(err)).
+					Print("get source files from dir error")
+				return nil, err
+			}
+			files = append(files, list...)
+		} else {
+			files = append(files, in)
+		}
+	}
+	return files, nil
+}
+
+// parseIdAllocator parses name and options of allocator formatted like name:options
+func parseIdAllocator(s string) (name, opts string) {
+	infos := strings.SplitN(s, ":", 2)
+	name = infos[0]
+	if len(infos) == 2 {
+		opts = infos[1]
+	}
+	return
+}
+
+// parseIdFor parses comma separated kinds of beans which should be allocated ids
+func parseIdFor(s string) map[string]bool {
+	idFor := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		idFor[strings.TrimSpace(f)] = true
+	}
+	return idFor
 }
 
 func filesInDir(dir string, filter func(os.FileInfo) bool) ([]string, error) {
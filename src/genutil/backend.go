@@ -0,0 +1,157 @@
+package genutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gopherd/log"
+	"github.com/mkideal/pkg/errors"
+
+	"github.com/midlang/mid/src/mid/build"
+)
+
+// Naming holds naming conventions of a language which are applied by template
+// functions typeName, fieldName and constName, names are kept as they are if
+// the function is nil
+type Naming struct {
+	// Type converts names of beans and enums
+	Type func(string) string
+	// Field converts names of fields
+	Field func(string) string
+	// Const converts names of constants and enum values
+	Const func(string) string
+}
+
+// apply converts name by convert, name is returned as it is if convert is nil
+func (naming Naming) apply(convert func(string) string, name string) string {
+	if convert == nil {
+		return name
+	}
+	return convert(name)
+}
+
+// TypeBuilderFunc creates functions for building types of a language,
+// builtin and container types should be mapped by mapper
+type TypeBuilderFunc func(mapper *TypeMapper, config build.PluginRuntimeConfig) (BuildTypeFunc, BuildFieldTypeFunc)
+
+// Backend is a language backend of the generator, backends register themselves
+// by RegisterBackend in init functions of their packages, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/golang"
+type Backend struct {
+	// Lang is language of the backend, e.g. go
+	Lang string
+	// TypeBuilder creates functions for building types
+	TypeBuilder TypeBuilderFunc
+	// Formatters format generated files by extension, e.g. ".go": GoFmt
+	Formatters map[string]FormatFunc
+	// Naming holds naming conventions of the language
+	Naming Naming
+}
+
+var backends = struct {
+	sync.RWMutex
+	m map[string]*Backend
+}{m: make(map[string]*Backend)}
+
+// RegisterBackend registers a language backend, it panics if backend of the language registered
+func RegisterBackend(backend *Backend) {
+	backends.Lock()
+	defer backends.Unlock()
+	if backend.Lang == "" || backend.TypeBuilder == nil {
+		panic("genutil: register backend with empty language or nil type builder")
+	}
+	if _, dup := backends.m[backend.Lang]; dup {
+		panic("genutil: backend " + backend.Lang + " registered twice")
+	}
+	backends.m[backend.Lang] = backend
+}
+
+// LookupBackend returns backend of language lang
+func LookupBackend(lang string) (*Backend, bool) {
+	backends.RLock()
+	defer backends.RUnlock()
+	backend, ok := backends.m[lang]
+	return backend, ok
+}
+
+// Backends returns sorted languages of registered backends
+func Backends() []string {
+	backends.RLock()
+	defer backends.RUnlock()
+	langs := make([]string, 0, len(backends.m))
+	for lang := range backends.m {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// NewGenerator creates a generator for the plugin with type mapper, formatters
+// and naming conventions of the backend
+func (backend *Backend) NewGenerator(plugin build.Plugin, config build.PluginRuntimeConfig) (*Generator, error) {
+	mapper, err := LoadTypeMapper(plugin, config)
+	if err != nil {
+		return nil, err
+	}
+	buildType, buildFieldType := backend.TypeBuilder(mapper, config)
+	generator := NewGenerator(buildType, plugin, config)
+	generator.SetBuildFieldType(buildFieldType)
+	for ext, formatter := range backend.Formatters {
+		generator.SetFormatter(ext, formatter)
+	}
+	generator.SetNaming(backend.Naming)
+	return generator, nil
+}
+
+// Main runs a generator plugin which is invoked by midc, the backend is
+// selected by language of the plugin from registered backends
+func Main() {
+	log.Start(log.WithSync(true), log.WithLevel(log.LevelWarn))
+
+	plugin, config, builder, err := build.ParseFlags()
+	log.If(err != nil).Fatal().
+		Error("err", err).
+		Print("ParseFlags error")
+	log.Debug().
+		Any("plugin", plugin).
+		Any("config", config).
+		Any("builder", builder).
+		Print("running plugin")
+
+	backend, ok := LookupBackend(plugin.Lang)
+	log.If(!ok).Fatal().
+		String("lang", plugin.Lang).
+		String("backends", strings.Join(Backends(), ",")).
+		Print("backend not found")
+
+	err = generate(backend, builder, plugin, config)
+	log.If(err != nil).Error().
+		Error("err", err).
+		Print("generate error")
+}
+
+// generate generates packages of builder by backend, panics are recovered as errors
+func generate(backend *Backend, builder *build.Builder, plugin build.Plugin, config build.PluginRuntimeConfig) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			switch x := e.(type) {
+			case error:
+				err = x
+			case string:
+				err = errors.Error(x)
+			default:
+				err = fmt.Errorf("%v", x)
+			}
+		}
+	}()
+
+	generator, err := backend.NewGenerator(plugin, config)
+	if err != nil {
+		return err
+	}
+	_, err = generator.GeneratePackages(builder.Packages)
+	return err
+}
@@ -0,0 +1,23 @@
+// Package cpp is the C++ backend of generator mid-gen, it registers itself by
+// importing the package, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/cpp"
+package cpp
+
+import (
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+func init() {
+	genutil.RegisterBackend(&genutil.Backend{
+		Lang:        "cpp",
+		TypeBuilder: newTypes,
+	})
+}
+
+// newTypes creates functions for building C++ types
+func newTypes(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) (genutil.BuildTypeFunc, genutil.BuildFieldTypeFunc) {
+	types := newTypeBuilder(mapper, config)
+	return types.buildType, types.buildFieldType
+}
@@ -0,0 +1,20 @@
+package cpp
+
+import (
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("cpp", "default"), "../../../../testdata/demo.mid")
+}
+
+func TestBeansTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/beans", gentest.KindOptions("cpp", "beans"), "../../../../testdata/demo.mid")
+}
+
+func TestTypeTagTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/typetags/default", gentest.KindOptions("cpp", "default"), "../../../../testdata/typetags.mid")
+	gentest.Run(t, "testdata/typetags/beans", gentest.KindOptions("cpp", "beans"), "../../../../testdata/typetags.mid")
+}
@@ -0,0 +1,31 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <optional>
+
+namespace demo {
+struct Info: public User {
+
+	std::string desc;
+	std::map<int64_t,std::vector<std::map<int,std::array<bool,5> > > >  xxx;
+	int a;
+	int8_t b;
+	int16_t c;
+	int32_t d;
+	int64_t e;
+	uint_t f;
+	uint8_t g;
+	uint16_t h;
+	uint32_t i;
+	uint64_t j;
+	bool k;
+	unsigned char l;
+	
+};
+}
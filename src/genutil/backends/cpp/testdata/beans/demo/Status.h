@@ -0,0 +1,11 @@
+
+
+namespace demo {
+
+// doc: Status
+enum Status {
+	Ok = 0,// ok
+	Bad = 1,// bad
+	
+};
+}
@@ -0,0 +1,21 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <optional>
+
+namespace demo {
+struct User {
+
+	std::optional<int64_t> id;
+	std::string name;
+	std::vector<std::string>  otherNames;
+	std::array<unsigned char,6>  code;
+	
+};
+}
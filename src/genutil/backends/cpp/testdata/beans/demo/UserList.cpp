@@ -0,0 +1,16 @@
+
+
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <unordered_map>
+
+namespace demo {
+
+
+
+
+
+}
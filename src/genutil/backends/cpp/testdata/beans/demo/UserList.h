@@ -0,0 +1,18 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <optional>
+
+namespace demo {
+struct UserList {
+
+	std::map<int64_t,User>  users;
+	
+};
+}
@@ -0,0 +1,21 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <functional>
+#include <tuple>
+
+namespace demo {
+class UserService {
+	 virtual void sayHello () = 0;
+	 virtual UserList getUsers () = 0;
+	 virtual User findUser (int64_t uid) = 0;
+	 virtual Status delUser (int64_t) = 0;
+	
+};
+}
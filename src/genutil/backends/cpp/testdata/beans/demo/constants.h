@@ -0,0 +1,14 @@
+
+
+namespace demo {
+
+	// constants
+const int A = 1;
+	const int B = 2;
+	
+
+	const int C = 3;
+	const int D = 4;
+	
+
+}
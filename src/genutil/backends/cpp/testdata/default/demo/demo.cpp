@@ -0,0 +1,17 @@
+
+#include "demo.h"
+
+namespace demo {
+
+
+
+
+
+
+
+
+
+
+} // end namespace demo
+
+
@@ -0,0 +1,76 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <functional>
+#include <tuple>
+#include <optional>
+#include <variant>
+
+namespace demo {
+
+
+// constants
+const int A = 1;
+const int B = 2;
+
+
+const int C = 3;
+const int D = 4;
+
+
+
+// doc: Status
+enum Status {
+	Ok = 0,// ok
+	Bad = 1,// bad
+	
+};
+
+struct User {
+	std::optional<int64_t> id;
+	std::string name;
+	std::vector<std::string>  otherNames;
+	std::array<unsigned char,6>  code;
+	
+};
+
+struct UserList {
+	std::map<int64_t,User>  users;
+	
+};
+
+class UserService {
+	 virtual void sayHello () = 0;
+	 virtual UserList getUsers () = 0;
+	 virtual User findUser (int64_t uid) = 0;
+	 virtual Status delUser (int64_t) = 0;
+	
+};
+
+struct Info: public User {
+	std::string desc;
+	std::map<int64_t,std::vector<std::map<int,std::array<bool,5> > > >  xxx;
+	int a;
+	int8_t b;
+	int16_t c;
+	int32_t d;
+	int64_t e;
+	uint_t f;
+	uint8_t g;
+	uint16_t h;
+	uint32_t i;
+	uint64_t j;
+	bool k;
+	unsigned char l;
+	
+};
+
+} // end namespace demo
+
+
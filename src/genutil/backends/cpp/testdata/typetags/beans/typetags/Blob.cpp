@@ -0,0 +1,18 @@
+
+
+
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <unordered_map>
+
+
+namespace typetags {
+
+
+
+
+
+}
@@ -0,0 +1,21 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <optional>
+
+namespace typetags {
+// Types of fields declared by tags like `go.type` are used as they are
+struct Blob {
+
+	Bytes data;
+	int64_t size;
+	Limit limit;
+	
+};
+}
@@ -0,0 +1,22 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <variant>
+
+namespace typetags {
+using Value = std::variant<std::monostate, std::string, Number, Blob>;
+// ValueKind represents index of Value
+enum ValueKind {
+	Value_None = 0,
+	Value_text = 1,
+	Value_number = 2,
+	Value_blob = 3,
+	
+};
+}
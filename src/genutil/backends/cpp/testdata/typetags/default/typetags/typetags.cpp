@@ -0,0 +1,9 @@
+
+#include "typetags.h"
+
+namespace typetags {
+
+
+} // end namespace typetags
+
+
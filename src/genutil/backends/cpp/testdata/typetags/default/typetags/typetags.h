@@ -0,0 +1,37 @@
+
+#include <string>
+#include <vector>
+#include <array>
+#include <map>
+#include <set>
+#include <unordered_map>
+#include <chrono>
+#include <cstdint>
+#include <functional>
+#include <tuple>
+#include <optional>
+#include <variant>
+
+namespace typetags {
+
+// Types of fields declared by tags like `go.type` are used as they are
+struct Blob {
+	Bytes data;
+	int64_t size;
+	Limit limit;
+	
+};
+
+using Value = std::variant<std::monostate, std::string, Number, Blob>;
+// ValueKind represents index of Value
+enum ValueKind {
+	Value_None = 0,
+	Value_text = 1,
+	Value_number = 2,
+	Value_blob = 3,
+	
+};
+
+} // end namespace typetags
+
+
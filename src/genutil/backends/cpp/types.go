@@ -0,0 +1,112 @@
+package cpp
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+const (
+	Env_unordered_map = "cpp:unordered_map"
+)
+
+// typeBuilder builds c++ types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+// newTypeBuilder creates a typeBuilder, map is mapped to std::unordered_map
+// if environment variable cpp:unordered_map is set
+func newTypeBuilder(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) typeBuilder {
+	if config.BoolEnv(Env_unordered_map) {
+		mapper.Override(map[string]string{
+			genutil.TypeKeyMap: "std::unordered_map<$K,$V> ",
+		})
+	}
+	return typeBuilder{mapper: mapper}
+}
+
+func (b typeBuilder) cppFieldDecl(f *build.Field) string {
+	return cppNamedDecl(b.buildType(f.Type), f.Names, "")
+}
+
+func cppNamedDecl(typ string, names []string, defaultName string) string {
+	if len(names) == 0 {
+		if defaultName == "" {
+			return typ
+		}
+		return typ + " " + defaultName
+	}
+	return typ + " " + strings.Join(names, ", ")
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		name := strings.Replace(t.Name, ".", "::", -1)
+		if t.Package != "" {
+			return t.Package + "::" + name
+		}
+		return name
+	case build.TypeBase, *build.TypeBase:
+		return "void"
+	case *build.FuncType:
+		var (
+			buf    bytes.Buffer
+			params []string
+		)
+		for _, field := range t.Params {
+			if t.ClientStream {
+				// client streaming parameter is read by a function until it returns false
+				params = append(params, cppNamedDecl("std::function<bool("+b.buildType(field.Type)+"&)>", field.Names, "reader"))
+			} else {
+				params = append(params, b.cppFieldDecl(field))
+			}
+		}
+		switch {
+		case t.ServerStream:
+			buf.WriteString("void")
+			params = append(params, "std::function<void(const "+b.buildType(t.Result)+"&)> writer")
+		case len(t.Results) == 0:
+			buf.WriteString("void")
+		case len(t.Results) == 1:
+			buf.WriteString(b.buildType(t.Result))
+		default:
+			buf.WriteString("std::tuple<")
+			for i, field := range t.Results {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				buf.WriteString(b.buildType(field.Type))
+			}
+			buf.WriteByte('>')
+		}
+		if t.Throws != nil {
+			params = append(params, b.buildType(t.Throws)+"& error")
+		}
+		buf.WriteByte('(')
+		buf.WriteString(strings.Join(params, ", "))
+		buf.WriteByte(')')
+		return buf.String()
+	default:
+		return ""
+	}
+}
+
+// buildFieldType builds optional field to std::optional,
+// type declared by field tag `cpp.type` is used as it is
+func (b typeBuilder) buildFieldType(field *build.Field) string {
+	if typ, ok := b.mapper.FieldType(field); ok {
+		return typ
+	}
+	typ := b.buildType(field.Type)
+	if field.IsOptional() {
+		return "std::optional<" + strings.TrimSpace(typ) + ">"
+	}
+	return typ
+}
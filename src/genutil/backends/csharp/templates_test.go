@@ -0,0 +1,19 @@
+package csharp
+
+import (
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("csharp", "default"), "../../../../testdata/demo.mid")
+}
+
+func TestTypeTagTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/typetags", gentest.KindOptions("csharp", "default"), "../../../../testdata/typetags.mid")
+}
+
+func TestNestedTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/nested", gentest.KindOptions("csharp", "default"), "../../../../testdata/nested.mid")
+}
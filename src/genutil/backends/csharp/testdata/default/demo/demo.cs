@@ -0,0 +1,52 @@
+
+using System;
+using System.Collections.Generic;
+
+namespace demo
+{
+
+// doc: Status
+public enum Status
+{
+	Ok = 0,// ok
+	Bad = 1,// bad
+	
+}
+
+public class User
+{
+	public Nullable<long> Id;
+	public string Name;
+	public string[] OtherNames;
+	public byte[] Code;
+	
+}
+
+public class UserList
+{
+	public Dictionary<long, User> Users;
+	
+}
+
+public class Info : User
+{
+	public string Desc;
+	public Dictionary<long, Dictionary<int, bool[]>[]> Xxx;
+	public int A;
+	public sbyte B;
+	public short C;
+	public int D;
+	public long E;
+	public uint F;
+	public byte G;
+	public ushort H;
+	public uint I;
+	public ulong J;
+	public bool K;
+	public byte L;
+	
+}
+
+}
+
+
@@ -0,0 +1,32 @@
+
+using System;
+using System.Collections.Generic;
+
+namespace nested
+{
+
+// Account declares nested types which have the same names as fields
+public class Account
+{
+public enum Kind
+{
+	Admin = 1,
+	Guest = 2,
+	
+}
+
+public class Profile
+{
+	public string Nick;
+	
+}
+
+	public Account.Kind Kind_;
+	public Account.Profile Profile_;
+	public string Account_;
+	
+}
+
+}
+
+
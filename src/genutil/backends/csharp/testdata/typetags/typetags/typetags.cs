@@ -0,0 +1,43 @@
+
+using System;
+using System.Collections.Generic;
+
+namespace typetags
+{
+
+// Types of fields declared by tags like `go.type` are used as they are
+public class Blob
+{
+	public Bytes Data;
+	public long Size;
+	public Limit Limit;
+	
+}
+
+public abstract class Value
+{
+	public abstract int UnionKind { get; }
+}
+
+public sealed class Value_Text : Value
+{
+	public override int UnionKind => 1;
+	public string Text;
+}
+
+public sealed class Value_Number : Value
+{
+	public override int UnionKind => 2;
+	public Number Number;
+}
+
+public sealed class Value_Blob : Value
+{
+	public override int UnionKind => 3;
+	public Blob Blob;
+}
+
+
+}
+
+
@@ -0,0 +1,88 @@
+package csharp
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// typeBuilder builds c# types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+func (b typeBuilder) csFieldDecl(f *build.Field, emptyIfNoName bool) string {
+	if len(f.Names) == 0 {
+		if emptyIfNoName {
+			return b.buildType(f.Type)
+		}
+		return "_ " + b.buildType(f.Type)
+	}
+	return strings.Join(f.Names, ", ") + " " + b.buildType(f.Type)
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		if t.Underlying != nil {
+			return b.buildType(t.Underlying)
+		}
+		if t.Package != "" {
+			return t.Package + "." + t.Name
+		}
+		return t.Name
+	case *build.FuncType:
+		var buf bytes.Buffer
+		buf.WriteByte('(')
+		if len(t.Params) > 0 {
+			allNoName := true
+			for _, field := range t.Params {
+				if len(field.Names) > 0 {
+					allNoName = false
+					break
+				}
+			}
+			for i, field := range t.Params {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(b.csFieldDecl(field, allNoName))
+			}
+		}
+		buf.WriteByte(')')
+		if t.Result != nil {
+			buf.WriteString(b.buildType(t.Result))
+		}
+		return buf.String()
+	default:
+		return ""
+	}
+}
+
+// buildFieldType builds optional field of value type to Nullable<T>,
+// type declared by field tag `csharp.type` is used as it is
+func (b typeBuilder) buildFieldType(field *build.Field) string {
+	if typ, ok := b.mapper.FieldType(field); ok {
+		return typ
+	}
+	typ := b.buildType(field.Type)
+	if field.IsOptional() && isValueType(field.Type) {
+		return "Nullable<" + typ + ">"
+	}
+	return typ
+}
+
+func isValueType(typ build.Type) bool {
+	typ = build.Underlying(typ)
+	if t, ok := typ.(*build.StructType); ok {
+		return t.Kind == lexer.ENUM.String()
+	}
+	return typ.IsInt() || typ.IsFloat() || typ.IsBool() ||
+		typ.IsTime() || typ.IsDuration() || typ.IsDecimal() || typ.IsUUID()
+}
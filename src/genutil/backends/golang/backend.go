@@ -0,0 +1,28 @@
+// Package golang is the Go backend of generator mid-gen, it registers itself by
+// importing the package, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/golang"
+package golang
+
+import (
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+func init() {
+	genutil.RegisterBackend(&genutil.Backend{
+		Lang:        "go",
+		TypeBuilder: newTypes,
+		Formatters:  map[string]genutil.FormatFunc{".go": genutil.GoFmt},
+		// exported fields are titled, e.g. {{fieldName "id"}} is Id
+		Naming: genutil.Naming{Field: strings.Title},
+	})
+}
+
+// newTypes creates functions for building Go types
+func newTypes(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) (genutil.BuildTypeFunc, genutil.BuildFieldTypeFunc) {
+	types := typeBuilder{mapper}
+	return types.buildType, types.buildFieldType
+}
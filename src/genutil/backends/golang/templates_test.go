@@ -0,0 +1,69 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("go", "default"), "../../../../testdata/demo.mid")
+}
+
+func TestBeansTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/beans", gentest.KindOptions("go", "beans"), "../../../../testdata/demo.mid")
+}
+
+func TestStorageTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/storage", gentest.KindOptions("go", "storage"), "../../../../testdata/storage.mid")
+}
+
+func TestStorageOnefileTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/storage_onefile", gentest.KindOptions("go", "storage_onefile"), "../../../../testdata/storage.mid")
+}
+
+func TestExtendedTemplates(t *testing.T) {
+	opts := gentest.KindOptions("go", "")
+	opts.TemplatesDir = "testdata/templates/mybeans/go"
+	gentest.Run(t, "testdata/mybeans", opts, "../../../../testdata/demo.mid")
+}
+
+func TestMemberTemplates(t *testing.T) {
+	opts := gentest.KindOptions("go", "")
+	opts.TemplatesDir = "testdata/templates/members/go"
+	gentest.Run(t, "testdata/members", opts, "../../../../testdata/demo.mid")
+}
+
+func TestMetaTemplates(t *testing.T) {
+	opts := gentest.KindOptions("go", "")
+	opts.TemplatesDir = "testdata/templates/meta/go"
+	gentest.Run(t, "testdata/meta", opts, "../../../../testdata/demo.mid")
+}
+
+func TestUnknownMetaKey(t *testing.T) {
+	opts := gentest.KindOptions("go", "")
+	opts.TemplatesDir = "testdata/templates/badmeta/go"
+	_, err := gentest.Generate(opts, "../../../../testdata/demo.mid")
+	if err == nil || !strings.Contains(err.Error(), `unknown meta key "notexists"`) {
+		t.Fatalf("want error of unknown meta key, got %v", err)
+	}
+}
+
+func TestTypeTagTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/typetags/default", gentest.KindOptions("go", "default"), "../../../../testdata/typetags.mid")
+	gentest.Run(t, "testdata/typetags/beans", gentest.KindOptions("go", "beans"), "../../../../testdata/typetags.mid")
+}
+
+// TestCodecExtension generates codes of codec extension into x/go/codec/internal/generated,
+// which are tested by x/go/codec
+func TestCodecExtension(t *testing.T) {
+	opts := gentest.KindOptions("go", "default")
+	opts.Extensions = []string{"codec"}
+	gentest.Run(t, "../../../../x/go/codec/internal/generated", opts, "../../../../testdata/presence.mid")
+}
+
+func TestThrowsTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/throws/default", gentest.KindOptions("go", "default"), "../../../../testdata/throws.mid")
+	gentest.Run(t, "testdata/throws/beans", gentest.KindOptions("go", "beans"), "../../../../testdata/throws.mid")
+}
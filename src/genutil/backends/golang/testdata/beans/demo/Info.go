@@ -0,0 +1,20 @@
+package demo
+
+type Info struct {
+	User
+
+	Desc string
+	Xxx  map[int64][]map[int][5]bool
+	A    int
+	B    int8
+	C    int16
+	D    int32
+	E    int64
+	F    uint
+	G    uint8
+	H    uint16
+	I    uint32
+	J    uint64
+	K    bool
+	L    byte
+}
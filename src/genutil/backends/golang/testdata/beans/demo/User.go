@@ -0,0 +1,8 @@
+package demo
+
+type User struct {
+	Id         *int64
+	Name       string
+	OtherNames []string
+	Code       [6]byte
+}
@@ -0,0 +1,5 @@
+package demo
+
+type UserList struct {
+	Users map[int64]User
+}
@@ -0,0 +1,8 @@
+package demo
+
+type UserService interface {
+	SayHello()
+	GetUsers() UserList
+	FindUser(uid int64) User
+	DelUser(int64) Status
+}
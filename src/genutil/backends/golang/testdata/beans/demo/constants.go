@@ -0,0 +1,12 @@
+package demo
+
+// constants
+const (
+	A = 1
+	B = 2
+)
+
+const (
+	C = 3
+	D = 4
+)
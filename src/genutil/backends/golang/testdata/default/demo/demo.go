@@ -0,0 +1,58 @@
+package demo
+
+// constants
+const (
+	A = 1
+	B = 2
+)
+
+const (
+	C = 3
+	D = 4
+)
+
+type Status int
+
+// doc: Status
+const (
+	Status_Ok  Status = 0 // ok
+	Status_Bad Status = 1 // bad
+
+)
+
+type User struct {
+	Id         *int64
+	Name       string
+	OtherNames []string
+	Code       [6]byte
+}
+
+type UserList struct {
+	Users map[int64]User
+}
+
+type UserService interface {
+	SayHello()
+	GetUsers() UserList
+	FindUser(uid int64) User
+	DelUser(int64) Status
+}
+
+type Info struct {
+	User
+
+	Desc string
+	Xxx  map[int64][]map[int][5]bool
+	A    int
+	B    int8
+	C    int16
+	D    int32
+	E    int64
+	F    uint
+	G    uint8
+	H    uint16
+	I    uint32
+	J    uint64
+	K    bool
+	L    byte
+}
@@ -0,0 +1,2 @@
+package demo
+const   Raw   =   true
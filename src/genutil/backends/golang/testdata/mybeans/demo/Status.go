@@ -0,0 +1,10 @@
+package demo
+
+type Status int
+
+// doc: Status
+const (
+	Status_Ok  Status = 0 // ok
+	Status_Bad Status = 1 // bad
+
+)
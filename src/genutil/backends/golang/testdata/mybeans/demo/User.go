@@ -0,0 +1,16 @@
+package demo
+
+import "fmt"
+
+type User struct {
+	Id         *int64
+	Name       string
+	OtherNames []string
+	Code       [6]byte
+}
+
+// Reset resets all fields of User
+func (x *User) Reset() { *x = User{} }
+
+// GoString implements fmt.GoStringer
+func (x User) GoString() string { return fmt.Sprintf("%T", x) }
@@ -0,0 +1,185 @@
+// NOTE: AUTO-GENERATED by midc, DON'T edit!!
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/mkideal/pkg/storage"
+	"github.com/mkideal/pkg/typeconv"
+	"gopkg.in/redis.v5"
+)
+
+var (
+	_ = fmt.Printf
+	_ = storage.Unused
+	_ = typeconv.Unused
+	_ = redis.Nil
+)
+
+// Table
+
+// doc: Account
+type Account struct {
+	Name      string  `xorm:"pk VARCHAR(32)"`
+	User      int64   `xorm:"BIGINT(20)"`
+	CreatedAt uint32  `xorm:"INT(10)"`
+	Inviter   *int64  `xorm:"BIGINT(20)"`
+	Remark    *string `xorm:"TEXT"`
+}
+
+func NewAccount() *Account {
+	return &Account{}
+}
+
+func (Account) Meta() AccountMeta            { return accountMetaVar }
+func (Account) TableMeta() storage.TableMeta { return accountMetaVar }
+func (x Account) Key() interface{}           { return x.Name }
+func (x *Account) SetKey(value string) error {
+	x.Name = value
+	return nil
+}
+
+func (x Account) GetField(field string) (interface{}, bool) {
+	switch field {
+	case accountMetaVar.F_user:
+		return x.User, true
+	case accountMetaVar.F_created_at:
+		return x.CreatedAt, true
+	case accountMetaVar.F_inviter:
+		if x.Inviter == nil {
+			return nil, true
+		}
+		return *x.Inviter, true
+	case accountMetaVar.F_remark:
+		if x.Remark == nil {
+			return nil, true
+		}
+		return *x.Remark, true
+	}
+	return nil, false
+}
+
+func (x *Account) SetField(field, value string) error {
+	switch field {
+	case accountMetaVar.F_user:
+		return typeconv.String2Int64(&x.User, value)
+	case accountMetaVar.F_created_at:
+		return typeconv.String2Uint32(&x.CreatedAt, value)
+	case accountMetaVar.F_inviter:
+		if x.Inviter == nil {
+			x.Inviter = new(int64)
+		}
+		return typeconv.String2Int64(x.Inviter, value)
+	case accountMetaVar.F_remark:
+		if x.Remark == nil {
+			x.Remark = new(string)
+		}
+		*x.Remark = value
+	}
+	return nil
+}
+
+// Meta
+type AccountMeta struct {
+	F_user       string
+	F_created_at string
+	F_inviter    string
+	F_remark     string
+}
+
+func (AccountMeta) Name() string     { return "account" }
+func (AccountMeta) Key() string      { return "name" }
+func (AccountMeta) Fields() []string { return _account_fields }
+
+var accountMetaVar = AccountMeta{
+	F_user:       "user",
+	F_created_at: "created_at",
+	F_inviter:    "inviter",
+	F_remark:     "remark",
+}
+
+var _account_fields = []string{
+	accountMetaVar.F_user,
+	accountMetaVar.F_created_at,
+	accountMetaVar.F_inviter,
+	accountMetaVar.F_remark,
+}
+
+// Slice
+type AccountSlice []Account
+
+func NewAccountSlice(cap int) *AccountSlice {
+	s := AccountSlice(make([]Account, 0, cap))
+	return &s
+}
+
+func (s AccountSlice) TableMeta() storage.TableMeta { return accountMetaVar }
+func (s AccountSlice) Len() int                     { return len(s) }
+func (s *AccountSlice) Slice() []Account            { return []Account(*s) }
+
+func (s *AccountSlice) New(table string, index int, key string) (storage.Table, error) {
+	for len(*s) <= index {
+		*s = append(*s, Account{})
+	}
+	x := &((*s)[index])
+	err := x.SetKey(key)
+	return x, err
+}
+
+// View
+type AccountView struct {
+	Account
+	User UserView
+}
+
+type AccountViewSlice []AccountView
+
+func NewAccountViewSlice(cap int) *AccountViewSlice {
+	s := AccountViewSlice(make([]AccountView, 0, cap))
+	return &s
+}
+
+func (s AccountViewSlice) TableMeta() storage.TableMeta { return accountMetaVar }
+func (s AccountViewSlice) Len() int                     { return len(s) }
+func (s *AccountViewSlice) Slice() []AccountView        { return []AccountView(*s) }
+
+func (s *AccountViewSlice) New(table string, index int, key string) (storage.Table, error) {
+	if table == "account" {
+		for len(*s) <= index {
+			x := Account{}
+			*s = append(*s, AccountView{Account: x})
+		}
+		x := &((*s)[index].Account)
+		err := x.SetKey(key)
+		return x, err
+	}
+	v := &((*s)[index])
+	for t, x := range v.tables() {
+		if t == table {
+			err := x.SetKey(key)
+			return x, err
+		}
+	}
+	return nil, storage.ErrTableNotFoundInView
+}
+
+var (
+	AccountViewVar  = AccountView{}
+	accountViewRefs = map[string]storage.View{
+		accountMetaVar.F_user: UserViewVar,
+	}
+)
+
+func (AccountView) TableMeta() storage.TableMeta  { return accountMetaVar }
+func (AccountView) Fields() storage.FieldList     { return storage.FieldSlice(accountMetaVar.Fields()) }
+func (AccountView) Refs() map[string]storage.View { return accountViewRefs }
+func (view *AccountView) tables() map[string]storage.Table {
+	m := make(map[string]storage.Table)
+	v1 := &view.User
+	for t, x := range v1.tables() {
+		m[t] = x
+	}
+	m["account"] = &view.Account
+	return m
+}
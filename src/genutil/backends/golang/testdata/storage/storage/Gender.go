@@ -0,0 +1,12 @@
+// NOTE: AUTO-GENERATED by midc, DON'T edit!!
+
+package storage
+
+type Gender int
+
+// doc: Gender
+const (
+	Gender_Male   Gender = 1 // male
+	Gender_Female Gender = 2 // female
+
+)
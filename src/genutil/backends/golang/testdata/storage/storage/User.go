@@ -0,0 +1,201 @@
+// NOTE: AUTO-GENERATED by midc, DON'T edit!!
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/mkideal/pkg/storage"
+	"github.com/mkideal/pkg/typeconv"
+	"gopkg.in/redis.v5"
+)
+
+var (
+	_ = fmt.Printf
+	_ = storage.Unused
+	_ = typeconv.Unused
+	_ = redis.Nil
+)
+
+// Table
+
+// doc: User
+type User struct {
+	Id    int64    `xorm:"pk BIGINT(20) autoincr"`       // user id
+	Name  string   `xorm:"VARCHAR(64)  DEFAULT 'guest'"` // user name
+	Level int32    `xorm:"INT(10)  DEFAULT 1"`
+	Vip   bool     `xorm:"TINYINT(1)"`
+	Tags  []string `xorm:"TEXT"`
+}
+
+func NewUser() *User {
+	return &User{Name: "guest", Level: 1}
+}
+
+func (User) Meta() UserMeta               { return userMetaVar }
+func (User) TableMeta() storage.TableMeta { return userMetaVar }
+func (x User) Key() interface{}           { return x.Id }
+func (x *User) SetKey(value string) error {
+	return typeconv.String2Int64(&x.Id, value)
+}
+
+func (x User) GetField(field string) (interface{}, bool) {
+	switch field {
+	case userMetaVar.F_name:
+		return x.Name, true
+	case userMetaVar.F_level:
+		return x.Level, true
+	case userMetaVar.F_vip:
+		return x.Vip, true
+	case userMetaVar.F_tags:
+		return x.Tags, true
+	}
+	return nil, false
+}
+
+func (x *User) SetField(field, value string) error {
+	switch field {
+	case userMetaVar.F_name:
+		x.Name = value
+	case userMetaVar.F_level:
+		return typeconv.String2Int32(&x.Level, value)
+	case userMetaVar.F_vip:
+		return typeconv.String2Bool(&x.Vip, value)
+	case userMetaVar.F_tags:
+		if x.Tags == nil {
+			x.Tags = make([]string, 0)
+		}
+		if err := typeconv.String2Object(&x.Tags, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Meta
+type UserMeta struct {
+	F_name  string
+	F_level string
+	F_vip   string
+	F_tags  string
+}
+
+func (UserMeta) Name() string     { return "user" }
+func (UserMeta) Key() string      { return "id" }
+func (UserMeta) Fields() []string { return _user_fields }
+
+var userMetaVar = UserMeta{
+	F_name:  "name",
+	F_level: "level",
+	F_vip:   "vip",
+	F_tags:  "tags",
+}
+
+var _user_fields = []string{
+	userMetaVar.F_name,
+	userMetaVar.F_level,
+	userMetaVar.F_vip,
+	userMetaVar.F_tags,
+}
+
+// Slice
+type UserSlice []User
+
+func NewUserSlice(cap int) *UserSlice {
+	s := UserSlice(make([]User, 0, cap))
+	return &s
+}
+
+func (s UserSlice) TableMeta() storage.TableMeta { return userMetaVar }
+func (s UserSlice) Len() int                     { return len(s) }
+func (s *UserSlice) Slice() []User               { return []User(*s) }
+
+func (s *UserSlice) New(table string, index int, key string) (storage.Table, error) {
+	for len(*s) <= index {
+		*s = append(*s, User{})
+	}
+	x := &((*s)[index])
+	err := x.SetKey(key)
+	return x, err
+}
+
+// View
+type UserView struct {
+	User
+}
+
+type UserViewSlice []UserView
+
+func NewUserViewSlice(cap int) *UserViewSlice {
+	s := UserViewSlice(make([]UserView, 0, cap))
+	return &s
+}
+
+func (s UserViewSlice) TableMeta() storage.TableMeta { return userMetaVar }
+func (s UserViewSlice) Len() int                     { return len(s) }
+func (s *UserViewSlice) Slice() []UserView           { return []UserView(*s) }
+
+func (s *UserViewSlice) New(table string, index int, key string) (storage.Table, error) {
+	if table == "user" {
+		for len(*s) <= index {
+			x := User{}
+			*s = append(*s, UserView{User: x})
+		}
+		x := &((*s)[index].User)
+		err := x.SetKey(key)
+		return x, err
+	}
+	v := &((*s)[index])
+	for t, x := range v.tables() {
+		if t == table {
+			err := x.SetKey(key)
+			return x, err
+		}
+	}
+	return nil, storage.ErrTableNotFoundInView
+}
+
+var (
+	UserViewVar  = UserView{}
+	userViewRefs = map[string]storage.View{}
+)
+
+func (UserView) TableMeta() storage.TableMeta  { return userMetaVar }
+func (UserView) Fields() storage.FieldList     { return storage.FieldSlice(userMetaVar.Fields()) }
+func (UserView) Refs() map[string]storage.View { return userViewRefs }
+func (view *UserView) tables() map[string]storage.Table {
+	m := make(map[string]storage.Table)
+	m["user"] = &view.User
+	return m
+}
+
+// Index
+type UserLevel struct{}
+
+var UserLevelVar = UserLevel{}
+
+func (UserLevel) TableMeta() storage.TableMeta { return userMetaVar }
+func (UserLevel) Name() string                 { return "userLevel" }
+
+func (index UserLevel) Update(session storage.Session, table storage.ReadonlyTable, key interface{}, updatedFields []string) error {
+	if !storage.ContainsField(updatedFields, userMetaVar.F_level) {
+		return nil
+	}
+	value, found := table.GetField(userMetaVar.F_level)
+	if !found {
+		return fmt.Errorf("field `%s` not found in table `%s`", userMetaVar.F_level, table.TableMeta().Name())
+	}
+	_level, ok := value.(int32)
+	if !ok {
+		return fmt.Errorf("type of field `%s` must be `int32`, but got `%T`", userMetaVar.F_level, value)
+	}
+	cache := session.Cache()
+	_, err := cache.ZAdd(storage.JoinIndexKey(session.Name(), index), redis.Z{Member: key, Score: float64(_level)})
+	return err
+}
+
+func (index UserLevel) Remove(session storage.Session, keys ...interface{}) error {
+	cache := session.Cache()
+	_, err := cache.ZRem(storage.JoinIndexKey(session.Name(), index), keys...)
+	return err
+}
@@ -0,0 +1,8 @@
+// NOTE: AUTO-GENERATED by midc, DON'T edit!!
+
+package storage
+
+// constants
+const (
+	MaxLevel = 100
+)
@@ -0,0 +1,24 @@
+/* NOTE: AUTO-GENERATED by midc, DON'T edit!! */
+
+CREATE TABLE IF NOT EXISTS `user` (
+	`id` BIGINT(20) AUTO_INCREMENT  COMMENT 'user id',
+	`name` VARCHAR(64)  DEFAULT 'guest' COMMENT 'user name',
+	`level` INT(10)  DEFAULT 1 ,
+	`vip` TINYINT(1)  DEFAULT 0 ,
+	`tags` TEXT   ,
+	PRIMARY KEY (`id`)
+)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `account` (
+	`name` VARCHAR(32)   ,
+	`user` BIGINT(20)   ,
+	`created_at` INT(10)   ,
+	`inviter` BIGINT(20)   ,
+	`remark` TEXT   ,
+	PRIMARY KEY (`name`)
+)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8mb4;
+
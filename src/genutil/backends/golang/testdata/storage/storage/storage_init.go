@@ -0,0 +1,22 @@
+// NOTE: AUTO-GENERATED by midc, DON'T edit!!
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/mkideal/pkg/storage"
+	"github.com/mkideal/pkg/typeconv"
+	"gopkg.in/redis.v5"
+)
+
+var (
+	_ = fmt.Printf
+	_ = storage.Unused
+	_ = typeconv.Unused
+	_ = redis.Nil
+)
+
+func Init(eng storage.Engine) {
+	eng.AddIndex(UserLevelVar)
+}
@@ -0,0 +1,15 @@
+package throws
+
+import "strconv"
+
+type ErrCode int
+
+// ErrCode is thrown by methods of Users
+const (
+	ErrCode_Ok       ErrCode = 0
+	ErrCode_NotFound ErrCode = 1
+)
+
+// Error implements error, methods which throw ErrCode return it as error,
+// and callers could get it back by errors.As
+func (x ErrCode) Error() string { return "ErrCode(" + strconv.Itoa(int(x)) + ")" }
@@ -0,0 +1,5 @@
+package throws
+
+type User struct {
+	Id int64
+}
@@ -0,0 +1,10 @@
+package throws
+
+type Users interface {
+
+	// Find returns error of type ErrCode if it fails
+	Find(id int64) (User, error)
+	// Login returns error of type ErrCode if it fails
+	Login(name string) (token string, expires int64, err error)
+	Ping()
+}
@@ -0,0 +1,28 @@
+package throws
+
+import "strconv"
+
+type ErrCode int
+
+// ErrCode is thrown by methods of Users
+const (
+	ErrCode_Ok       ErrCode = 0
+	ErrCode_NotFound ErrCode = 1
+)
+
+// Error implements error, methods which throw ErrCode return it as error,
+// and callers could get it back by errors.As
+func (x ErrCode) Error() string { return "ErrCode(" + strconv.Itoa(int(x)) + ")" }
+
+type User struct {
+	Id int64
+}
+
+type Users interface {
+
+	// Find returns error of type ErrCode if it fails
+	Find(id int64) (User, error)
+	// Login returns error of type ErrCode if it fails
+	Login(name string) (token string, expires int64, err error)
+	Ping()
+}
@@ -0,0 +1,8 @@
+package typetags
+
+// Types of fields declared by tags like `go.type` are used as they are
+type Blob struct {
+	Data  RawBytes
+	Size  int64
+	Limit Limit
+}
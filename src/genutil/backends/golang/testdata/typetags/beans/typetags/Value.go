@@ -0,0 +1,23 @@
+package typetags
+
+type Value interface {
+	UnionKind() int
+}
+
+type Value_Text struct {
+	Text string
+}
+
+func (Value_Text) UnionKind() int { return 1 }
+
+type Value_Number struct {
+	Number Number
+}
+
+func (Value_Number) UnionKind() int { return 2 }
+
+type Value_Blob struct {
+	Blob Blob
+}
+
+func (Value_Blob) UnionKind() int { return 3 }
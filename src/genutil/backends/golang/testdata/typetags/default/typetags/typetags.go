@@ -0,0 +1,30 @@
+package typetags
+
+// Types of fields declared by tags like `go.type` are used as they are
+type Blob struct {
+	Data  RawBytes
+	Size  int64
+	Limit Limit
+}
+
+type Value interface {
+	UnionKind() int
+}
+
+type Value_Text struct {
+	Text string
+}
+
+func (Value_Text) UnionKind() int { return 1 }
+
+type Value_Number struct {
+	Number Number
+}
+
+func (Value_Number) UnionKind() int { return 2 }
+
+type Value_Blob struct {
+	Blob Blob
+}
+
+func (Value_Blob) UnionKind() int { return 3 }
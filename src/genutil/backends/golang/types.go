@@ -0,0 +1,134 @@
+package golang
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+// typeBuilder builds go types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+func (b typeBuilder) goFieldDecl(f *build.Field, emptyIfNoName bool) string {
+	if len(f.Names) == 0 {
+		if emptyIfNoName {
+			return b.buildType(f.Type)
+		}
+		return "_ " + b.buildType(f.Type)
+	}
+	return strings.Join(f.Names, ", ") + " " + b.buildType(f.Type)
+}
+
+// chanType represents receive-only channel of streaming parameter or result
+type chanType struct {
+	build.TypeBase
+	T build.Type
+}
+
+// goResults builds results of method, results of streaming method are channels
+// and `error` appended if method throws
+func (b typeBuilder) goResults(t *build.FuncType) string {
+	results := t.Results
+	if t.ServerStream && t.Result != nil {
+		results = []*build.Field{{Type: &chanType{T: t.Result}}}
+	}
+	named := len(results) > 0
+	for _, field := range results {
+		if len(field.Names) == 0 {
+			named = false
+			break
+		}
+	}
+	if t.Throws != nil {
+		errField := &build.Field{Type: &build.StructType{Name: "error"}}
+		if named {
+			errField.Names = []string{"err"}
+		}
+		results = append(results[:len(results):len(results)], errField)
+	}
+	if len(results) == 0 {
+		return ""
+	}
+	if len(results) == 1 && !named {
+		return b.buildType(results[0].Type)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for i, field := range results {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(b.goFieldDecl(field, !named))
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		// nested bean Outer.Inner is declared as Outer_Inner
+		name := strings.Replace(t.Name, ".", "_", -1)
+		if t.Package != "" {
+			return t.Package + "." + name
+		}
+		return name
+	case *chanType:
+		return "<-chan " + b.buildType(t.T)
+	case *build.FuncType:
+		var buf bytes.Buffer
+		buf.WriteByte('(')
+		if len(t.Params) > 0 {
+			allNoName := true
+			for _, field := range t.Params {
+				if len(field.Names) > 0 {
+					allNoName = false
+					break
+				}
+			}
+			for i, field := range t.Params {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if t.ClientStream {
+					// client streaming parameter received from a channel
+					field = &build.Field{Names: field.Names, Type: &chanType{T: field.Type}}
+				}
+				buf.WriteString(b.goFieldDecl(field, allNoName))
+			}
+		}
+		buf.WriteByte(')')
+		buf.WriteString(b.goResults(t))
+		return buf.String()
+	default:
+		return ""
+	}
+}
+
+// buildFieldType builds optional field to pointer type unless the type could be nil,
+// type declared by field tag `go.type` is used as it is
+func (b typeBuilder) buildFieldType(field *build.Field) string {
+	if typ, ok := b.mapper.FieldType(field); ok {
+		return typ
+	}
+	typ := b.buildType(field.Type)
+	if field.IsOptional() && !isNilable(field.Type) {
+		return "*" + typ
+	}
+	return typ
+}
+
+func isNilable(typ build.Type) bool {
+	typ = build.Underlying(typ)
+	if t, ok := typ.(*build.MapType); ok && t.Ordered {
+		return false
+	}
+	return typ.IsVector() || typ.IsMap() || typ.IsSet() || typ.IsUnion()
+}
@@ -0,0 +1,24 @@
+// Package js is the JavaScript backend of generator mid-gen, it registers itself by
+// importing the package, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/js"
+package js
+
+import (
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+func init() {
+	genutil.RegisterBackend(&genutil.Backend{
+		Lang:        "js",
+		TypeBuilder: newTypes,
+	})
+}
+
+// newTypes creates functions for building JavaScript types, fields have no types
+// in JavaScript, so there is no function for building types of fields
+func newTypes(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) (genutil.BuildTypeFunc, genutil.BuildFieldTypeFunc) {
+	types := typeBuilder{mapper}
+	return types.buildType, nil
+}
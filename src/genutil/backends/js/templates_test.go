@@ -0,0 +1,11 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("js", "default"), "../../../../testdata/demo.mid")
+}
@@ -0,0 +1,75 @@
+
+
+
+
+// constants
+
+const A = 1;
+const B = 2;
+
+
+
+const C = 3;
+const D = 4;
+
+
+
+// doc: Status
+
+const Status_Ok = 0;// ok
+const Status_Bad = 1;// bad
+
+
+class User {
+	constructor() {
+		
+		this.id = 0;
+		this.name = "";
+		this.otherNames = [];
+		this.code = new Array(6);
+		
+	}
+}
+
+class UserList {
+	constructor() {
+		
+		this.users = {};
+		
+	}
+}
+
+class UserService {
+	constructor() {
+		
+		this.sayHello = function() {};
+		this.getUsers = function() {};
+		this.findUser = function() {};
+		this.delUser = function() {};
+		
+	}
+}
+
+class Info extends User {
+	constructor() {
+		super();
+		this.desc = "";
+		this.xxx = {};
+		this.a = 0;
+		this.b = 0;
+		this.c = 0;
+		this.d = 0;
+		this.e = 0;
+		this.f = 0;
+		this.g = 0;
+		this.h = 0;
+		this.i = 0;
+		this.j = 0;
+		this.k = false;
+		this.l = 0;
+		
+	}
+}
+
+
+
@@ -0,0 +1,53 @@
+package js
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+// typeBuilder builds javascript types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+func (b typeBuilder) jsFieldDecl(f *build.Field, emptyIfNoName bool) string {
+	if len(f.Names) == 0 {
+		return b.buildType(f.Type)
+	}
+	return strings.Join(f.Names, ", ")
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		return t.Name
+	case *build.FuncType:
+		var buf bytes.Buffer
+		buf.WriteByte('(')
+		if len(t.Params) > 0 {
+			allNoName := true
+			for _, field := range t.Params {
+				if len(field.Names) > 0 {
+					allNoName = false
+					break
+				}
+			}
+			for i, field := range t.Params {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(b.jsFieldDecl(field, allNoName))
+			}
+		}
+		buf.WriteByte(')')
+		return buf.String()
+	default:
+		return ""
+	}
+}
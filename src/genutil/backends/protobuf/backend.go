@@ -0,0 +1,27 @@
+// Package protobuf is the Protobuf backend of generator mid-gen, it registers itself by
+// importing the package, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/protobuf"
+package protobuf
+
+import (
+	"github.com/mkideal/pkg/textutil/namemapper"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+func init() {
+	genutil.RegisterBackend(&genutil.Backend{
+		Lang:        "protobuf",
+		TypeBuilder: newTypes,
+		// fields are named in snake case, e.g. {{fieldName "userId"}} is user_id
+		Naming: genutil.Naming{Field: namemapper.UnderScore},
+	})
+}
+
+// newTypes creates functions for building Protobuf types
+func newTypes(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) (genutil.BuildTypeFunc, genutil.BuildFieldTypeFunc) {
+	types := typeBuilder{mapper}
+	return types.buildType, types.buildFieldType
+}
@@ -0,0 +1,25 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("protobuf", "default"), "../../../../testdata/demo.mid")
+}
+
+func TestNamingTemplates(t *testing.T) {
+	opts := gentest.KindOptions("protobuf", "")
+	opts.TemplatesDir = "testdata/templates/naming/protobuf"
+	gentest.Run(t, "testdata/naming", opts, "../../../../testdata/demo.mid")
+}
+
+func TestTypeTagTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/typetags", gentest.KindOptions("protobuf", "default"), "../../../../testdata/typetags.mid")
+}
+
+func TestInheritanceTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/inherit", gentest.KindOptions("protobuf", "default"), "../../../../testdata/inherit.mid")
+}
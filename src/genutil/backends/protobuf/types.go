@@ -0,0 +1,49 @@
+package protobuf
+
+import (
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+// typeBuilder builds protobuf types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+func (b typeBuilder) goFieldDecl(f *build.Field, emptyIfNoName bool) string {
+	if len(f.Names) == 0 {
+		if emptyIfNoName {
+			return b.buildType(f.Type)
+		}
+		return "_ " + b.buildType(f.Type)
+	}
+	return strings.Join(f.Names, ", ") + " " + b.buildType(f.Type)
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		if t.Underlying != nil {
+			return b.buildType(t.Underlying)
+		}
+		if t.Package != "" {
+			return t.Package + "." + t.Name
+		}
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+// buildFieldType builds type of field, type declared by field tag `protobuf.type` is used as it is
+func (b typeBuilder) buildFieldType(field *build.Field) string {
+	if typ, ok := b.mapper.FieldType(field); ok {
+		return typ
+	}
+	return b.buildType(field.Type)
+}
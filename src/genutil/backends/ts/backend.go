@@ -0,0 +1,23 @@
+// Package ts is the TypeScript backend of generator mid-gen, it registers itself by
+// importing the package, e.g.
+//
+//	import _ "github.com/midlang/mid/src/genutil/backends/ts"
+package ts
+
+import (
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+func init() {
+	genutil.RegisterBackend(&genutil.Backend{
+		Lang:        "ts",
+		TypeBuilder: newTypes,
+	})
+}
+
+// newTypes creates functions for building TypeScript types
+func newTypes(mapper *genutil.TypeMapper, config build.PluginRuntimeConfig) (genutil.BuildTypeFunc, genutil.BuildFieldTypeFunc) {
+	types := typeBuilder{mapper}
+	return types.buildType, types.buildFieldType
+}
@@ -0,0 +1,28 @@
+package ts
+
+import (
+	"testing"
+
+	"github.com/midlang/mid/src/genutil/gentest"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/default", gentest.KindOptions("ts", "default"), "../../../../testdata/demo.mid")
+}
+
+func TestImportTemplates(t *testing.T) {
+	opts := gentest.KindOptions("ts", "")
+	opts.TemplatesDir = "testdata/templates/imports/ts"
+	gentest.Run(t, "testdata/imports", opts, "../../../../testdata/demo.mid")
+}
+
+func TestTypeMapTemplates(t *testing.T) {
+	opts := gentest.KindOptions("ts", "")
+	opts.TemplatesDir = "testdata/templates/mytypes/ts"
+	opts.Types = map[string]string{"int64": "bigint"}
+	gentest.Run(t, "testdata/mytypes", opts, "testdata/types.mid")
+}
+
+func TestTypeTagTemplates(t *testing.T) {
+	gentest.Run(t, "testdata/typetags", gentest.KindOptions("ts", "default"), "../../../../testdata/typetags.mid")
+}
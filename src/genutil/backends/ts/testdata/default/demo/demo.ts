@@ -0,0 +1,64 @@
+
+
+
+
+// constants
+
+export const A = 1;
+export const B = 2;
+
+
+
+export const C = 3;
+export const D = 4;
+
+
+
+// doc: Status
+export enum Status {
+	Ok = 0,// ok
+	Bad = 1,// bad
+	
+}
+
+export class User {
+	id: number | undefined = undefined;
+	name: string = "";
+	otherNames: string[] = [];
+	code: number[] = new Array(6);
+	
+}
+
+export class UserList {
+	users: {[key: number]: User} = {};
+	
+}
+
+export interface UserService {
+	sayHello(): void;
+	getUsers(): UserList;
+	findUser(uid: number): User;
+	delUser(arg0: number): Status;
+	
+}
+
+export class Info extends User {
+	desc: string = "";
+	xxx: {[key: number]: {[key: number]: boolean[]}[]} = {};
+	a: number = 0;
+	b: number = 0;
+	c: number = 0;
+	d: number = 0;
+	e: number = 0;
+	f: number = 0;
+	g: number = 0;
+	h: number = 0;
+	i: number = 0;
+	j: number = 0;
+	k: boolean = false;
+	l: number = 0;
+	
+}
+
+
+
@@ -0,0 +1,5 @@
+import { Info, Status, User, UserList, UserService } from "./beans";
+import * as Long from "long";
+import "reflect-metadata";
+
+export const Package = "demo";
@@ -0,0 +1,14 @@
+
+
+
+export class Blob {
+	data: Buffer = [];
+	size: bigint = 0;
+	counts: Map<string, number> = {};
+	id: UUID = "";
+	chunks: Buffer[] | undefined = undefined;
+	
+}
+
+
+
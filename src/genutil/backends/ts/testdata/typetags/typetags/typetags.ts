@@ -0,0 +1,19 @@
+
+
+
+// Types of fields declared by tags like `go.type` are used as they are
+export class Blob {
+	data: Uint8Array = [];
+	size: number = 0;
+	limit: Limit = undefined;
+	
+}
+
+export type Value =
+	| null
+	| { kind: "text"; text: string }
+	| { kind: "number"; number: Number }
+	| { kind: "blob"; blob: Blob };
+
+
+
@@ -0,0 +1,110 @@
+package ts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+)
+
+// typeBuilder builds typescript types, builtin and container types are mapped by mapper
+type typeBuilder struct {
+	mapper *genutil.TypeMapper
+}
+
+func (b typeBuilder) tsFieldDecl(f *build.Field, index int) string {
+	if len(f.Names) == 0 {
+		return fmt.Sprintf("arg%d: %s", index, b.buildType(f.Type))
+	}
+	return strings.Join(f.Names, ", ") + ": " + b.buildType(f.Type)
+}
+
+func (b typeBuilder) buildType(typ build.Type) string {
+	if mapped, ok := b.mapper.MapType(typ, b.buildType); ok {
+		return mapped
+	}
+	switch t := typ.(type) {
+	case *build.StructType:
+		return t.Name
+	case *asyncIterableType:
+		return "AsyncIterable<" + b.buildType(t.T) + ">"
+	case *build.FuncType:
+		var buf bytes.Buffer
+		buf.WriteByte('(')
+		for i, field := range t.Params {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if t.ClientStream {
+				field = &build.Field{Names: field.Names, Type: &asyncIterableType{T: field.Type}}
+			}
+			buf.WriteString(b.tsFieldDecl(field, i))
+		}
+		buf.WriteString("): ")
+		buf.WriteString(b.tsResults(t))
+		return buf.String()
+	default:
+		return ""
+	}
+}
+
+// asyncIterableType represents streaming parameter or result
+type asyncIterableType struct {
+	build.TypeBase
+	T build.Type
+}
+
+// tsResults builds result type of method: multiple named results become an object type
+// while unnamed ones become a tuple
+func (b typeBuilder) tsResults(t *build.FuncType) string {
+	switch {
+	case t.ServerStream:
+		return b.buildType(&asyncIterableType{T: t.Result})
+	case len(t.Results) == 0:
+		return "void"
+	case len(t.Results) == 1 && len(t.Results[0].Names) == 0:
+		return b.buildType(t.Result)
+	}
+	var (
+		buf   bytes.Buffer
+		named = len(t.Results[0].Names) > 0
+	)
+	if named {
+		buf.WriteString("{ ")
+	} else {
+		buf.WriteByte('[')
+	}
+	for i, field := range t.Results {
+		if named {
+			for _, name := range field.Names {
+				buf.WriteString(name + ": " + b.buildType(field.Type) + "; ")
+			}
+		} else {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(b.buildType(field.Type))
+		}
+	}
+	if named {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return buf.String()
+}
+
+// buildFieldType builds optional field to `T | undefined`,
+// type declared by field tag `ts.type` is used as it is
+func (b typeBuilder) buildFieldType(field *build.Field) string {
+	if typ, ok := b.mapper.FieldType(field); ok {
+		return typ
+	}
+	typ := b.buildType(field.Type)
+	if field.IsOptional() {
+		return typ + " | undefined"
+	}
+	return typ
+}
@@ -2,7 +2,11 @@ package genutil
 
 import (
 	"bytes"
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
+	"text/template"
 
 	"github.com/midlang/mid/src/mid/build"
 )
@@ -27,7 +31,17 @@ type Context struct {
 	Beans map[string]*build.Bean
 
 	// BuildType functions for current language
-	buildType BuildTypeFunc
+	buildType      BuildTypeFunc
+	buildFieldType BuildFieldTypeFunc
+	// funcs holds template functions bound to the context
+	funcs template.FuncMap
+	// bases holds directories of base kinds of current template
+	bases []string
+	// imports collects imports required by current generated file
+	imports         *ImportSet
+	importFormatter ImportFormatter
+	// naming holds naming conventions of current language
+	naming Naming
 
 	Filename string
 }
@@ -39,14 +53,107 @@ func NewContext(
 	config build.PluginRuntimeConfig,
 ) *Context {
 	ctx := &Context{
-		buildType: buildType,
-		Plugin:    plugin,
-		Config:    config,
-		Beans:     make(map[string]*build.Bean),
+		buildType:       buildType,
+		Plugin:          plugin,
+		Config:          config,
+		Beans:           make(map[string]*build.Bean),
+		imports:         NewImportSet(),
+		importFormatter: importFormatters[plugin.Lang],
 	}
+	ctx.funcs = newFuncs(ctx)
 	return ctx
 }
 
+// derive creates a copy of ctx with pwd, template functions of the copy are bound to itself
+func (ctx *Context) derive(pwd string) *Context {
+	c := *ctx
+	c.Pwd = pwd
+	c.funcs = newFuncs(&c)
+	return &c
+}
+
+// IncludePath returns directories for searching included templates in order:
+// `includes` of templates directory and its base kinds, `_common/<lang>` beside
+// the template kind directory and in templates root directory, and templates of extensions
+func (ctx *Context) IncludePath() []string {
+	dirs := []string{filepath.Join(ctx.Plugin.TemplatesDir, IncludesDir)}
+	for _, base := range ctx.bases {
+		dirs = append(dirs, filepath.Join(base, IncludesDir))
+	}
+	for _, root := range ctx.templateRoots() {
+		dirs = append(dirs, filepath.Join(root, CommonDir, ctx.Plugin.Lang))
+	}
+	for _, ext := range ctx.Config.Extensions {
+		dirs = append(dirs, filepath.Join(ctx.Config.ExtentionsDir, ext.Path, "templates", ctx.Plugin.Lang))
+	}
+	return dirs
+}
+
+// templateRoots returns directories which contain template kinds: the parent
+// directory of current template kind and templates root directory of config
+func (ctx *Context) templateRoots() []string {
+	return templateRoots(ctx.Plugin, ctx.Config)
+}
+
+// templateRoots returns template roots of plugin with config, see Context.templateRoots
+func templateRoots(plugin build.Plugin, config build.PluginRuntimeConfig) []string {
+	// templates directory likes <root>/<kind>/<lang>
+	roots := []string{filepath.Dir(filepath.Dir(plugin.TemplatesDir))}
+	if root := config.TemplatesRootDir; root != "" {
+		if abs, err := filepath.Abs(root); err == nil && abs != roots[0] {
+			roots = append(roots, abs)
+		}
+	}
+	return roots
+}
+
+// findInclude finds included file by name. Absolute names are used as they are,
+// names begin with `./` or `../` are relative to directory of current template,
+// and other names are searched in IncludePath. If logical is true, the name
+// could omit suffix `.temp`, e.g. `head.go` for `head.go.temp`.
+func (ctx *Context) findInclude(name string, logical bool) (string, error) {
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	names := []string{name}
+	if logical && !strings.HasSuffix(name, TemplateFileSuffix) {
+		names = append(names, name+TemplateFileSuffix)
+	}
+	dirs := ctx.IncludePath()
+	if isRelativePath(name) {
+		dirs = []string{ctx.Pwd}
+	}
+	for _, dir := range dirs {
+		for _, name := range names {
+			filename := filepath.Join(dir, name)
+			if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+				return filename, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("include %s: not found in %s", name, strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// isRelativePath reports whether path begins with `./` or `../`
+func isRelativePath(path string) bool {
+	path = filepath.ToSlash(path)
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
+// executeFile parses and executes template file with data in a context derived
+// from ctx whose Pwd is directory of the file. Meta header would be ignored.
+func (ctx *Context) executeFile(filename string, data interface{}) (string, error) {
+	dir, _ := filepath.Split(filename)
+	c := ctx.derive(dir)
+	_, temp, err := ParseTemplateFile(filename, c.funcs)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = temp.Execute(&buf, data)
+	return buf.String(), err
+}
+
 func (ctx *Context) initWithPkg(pkg *build.Package) {
 	ctx.Pkg = pkg
 	ctx.Beans = make(map[string]*build.Bean)
@@ -62,6 +169,15 @@ func (ctx *Context) BuildType(typ build.Type) string {
 	return ctx.buildType(typ)
 }
 
+// BuildFieldType builds type of field, it's same as BuildType(field.Type) if
+// the language has no special treatment for the field
+func (ctx *Context) BuildFieldType(field *build.Field) string {
+	if ctx.buildFieldType != nil {
+		return ctx.buildFieldType(field)
+	}
+	return ctx.buildType(field.Type)
+}
+
 // Getenv gets custom envvar
 func (ctx *Context) Getenv(key string) string {
 	if ctx.Config.Envvars == nil {
@@ -110,17 +226,11 @@ func (ctx *Context) Extension(at string, data interface{}) (string, error) {
 				filename = filepath.Join(extdir, filename)
 			}
 			// NOTE: meta header would be ignored
-			_, temp, err := ParseTemplateFile(filename)
-			if err != nil {
-				return "", err
-			}
-			pwd := context.Pwd
-			ctx.Pwd, _ = filepath.Split(filename)
-			err = temp.Execute(&buf, data)
-			context.Pwd = pwd
+			content, err := ctx.executeFile(filename, data)
 			if err != nil {
 				return "", err
 			}
+			buf.WriteString(content)
 		}
 	}
 	return buf.String(), nil
@@ -128,7 +238,16 @@ func (ctx *Context) Extension(at string, data interface{}) (string, error) {
 
 // JSInitValue returns init value of javascript by Type
 func (ctx *Context) JSInitValue(typ build.Type) string {
+	typ = build.Underlying(typ)
 	switch {
+	case typ.IsTime():
+		return "new Date(0)"
+	case typ.IsDuration():
+		return "0"
+	case typ.IsDecimal():
+		return `"0"`
+	case typ.IsUUID():
+		return `""`
 	case typ.IsInt():
 		return "0"
 	case typ.IsBool():
@@ -145,17 +264,31 @@ func (ctx *Context) JSInitValue(typ build.Type) string {
 		}
 	case typ.IsVector():
 		return "[]"
+	case typ.IsSet():
+		return "new Set()"
 	case typ.IsMap():
+		if t, ok := typ.(*build.MapType); ok && t.Ordered {
+			return "new Map()"
+		}
 		return "{}"
+	case typ.IsUnion():
+		return "null"
+	case typ.IsEnum():
+		return "0"
 	case typ.IsStruct():
-		t, ok := typ.(*build.StructType)
-		if ok {
-			if bean := ctx.Pkg.FindBean(t.Name); bean != nil && bean.Kind == "enum" {
-				return "0"
-			} else {
-				return "new " + t.String(".") + "()"
-			}
+		if t, ok := typ.(*build.StructType); ok {
+			return "new " + t.String(".") + "()"
 		}
 	}
 	return "null"
 }
+
+// TSInitValue returns init value of typescript by Type
+func (ctx *Context) TSInitValue(typ build.Type) string {
+	value := ctx.JSInitValue(typ)
+	if t, ok := typ.(*build.StructType); ok && t.Underlying != nil {
+		// type of `type` bean is a branded type
+		value += " as " + ctx.BuildType(t)
+	}
+	return value
+}
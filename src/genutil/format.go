@@ -1,34 +1,32 @@
 package genutil
 
 import (
-	"os"
-	"os/exec"
-	"strings"
+	"go/format"
 )
 
-// GoFmt formats go code file
-func GoFmt(filename string) error {
-	if info, err := os.Stat(filename); err != nil || info == nil {
-		// skip wrong file
-		return nil
-	}
-	if !strings.HasSuffix(filename, ".go") {
-		// ignore non-golang file
-		return nil
-	}
-	const gofmt = "gofmt"
-	if _, err := exec.LookPath(gofmt); err != nil {
-		// do nothing if failed to lookup `gofmt`
-		return nil
+// Names of builtin formatters which could be used by meta `format`
+const (
+	// FormatterNone writes files as they are
+	FormatterNone = "none"
+	// FormatterGoFmt formats files by GoFmt
+	FormatterGoFmt = "gofmt"
+)
+
+// builtinFormatters returns named builtin formatters
+func builtinFormatters() map[string]FormatFunc {
+	return map[string]FormatFunc{
+		FormatterNone:  nil,
+		FormatterGoFmt: GoFmt,
 	}
-	cmd := exec.Command(gofmt, "-w", filename)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
 }
 
-// CppFormat formats cpp code file
-func CppFormat(filename string) error {
+// GoFmt formats go code
+func GoFmt(filename string, src []byte) ([]byte, error) {
+	return format.Source(src)
+}
+
+// CppFormat formats cpp code
+func CppFormat(filename string, src []byte) ([]byte, error) {
 	//TODO
-	return nil
+	return src, nil
 }
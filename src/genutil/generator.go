@@ -8,8 +8,11 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/gopherd/log"
@@ -17,17 +20,21 @@ import (
 	"github.com/mkideal/pkg/textutil/namemapper"
 
 	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
 )
 
 // BuildTypeFunc is a function type which used to build `build.Type` to a string
 type BuildTypeFunc func(build.Type) string
 
-var (
-	// funcs holds all shared template functions
-	funcs template.FuncMap
-	// context holds current context information
-	context *Context
-)
+// BuildFieldTypeFunc is a function type which used to build type of `build.Field`
+// to a string, e.g. optional field int64 may be built to *int64 in go
+type BuildFieldTypeFunc func(*build.Field) string
+
+// positioner is implemented by nodes which hold position in mid source file,
+// e.g. Bean, Field, ConstSpec
+type positioner interface {
+	Position() lexer.Position
+}
 
 func firstOf(sep, s string) string {
 	return nthOf(sep, s, 0)
@@ -134,41 +141,121 @@ func toInt(x any) int64 {
 	}
 }
 
-func includeTemplate(filename string, data interface{}) (string, error) {
-	if !filepath.IsAbs(filename) {
-		filename = filepath.Join(context.Plugin.TemplatesDir, IncludesDir, filename)
-	}
-	_, temp, err := ParseTemplateFile(filename)
+// includeTemplate executes template file with data in a context derived from ctx,
+// filename could be a logical name which is searched in include path of ctx
+func includeTemplate(ctx *Context, filename string, data interface{}) (string, error) {
+	filename, err := ctx.findInclude(filename, true)
 	if err != nil {
 		return "", err
 	}
+	return ctx.executeFile(filename, data)
+}
+
+// execute executes temp with data, replaces the placeholder of imports and closes file
+func (ctx *Context) execute(temp *Template, file io.WriteCloser, data interface{}) error {
+	ctx.imports = NewImportSet()
 	var buf bytes.Buffer
-	pwd := context.Pwd
-	context.Pwd, _ = filepath.Split(filename)
-	err = temp.Execute(&buf, data)
-	context.Pwd = pwd
-	return buf.String(), err
+	err := temp.Execute(&buf, data)
+	if err == nil {
+		_, err = file.Write(replaceImports(temp.Name(), buf.Bytes(), ctx.imports, ctx.importFormatter))
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-// Init initializes generator
-// NOTE: You MUST initialize generator before using generator
+// Generator generates codes of packages by templates for a language plugin.
+// Each package is generated with its own Context, so packages can be generated concurrently.
+type Generator struct {
+	plugin          build.Plugin
+	config          build.PluginRuntimeConfig
+	buildType       BuildTypeFunc
+	buildFieldType  BuildFieldTypeFunc
+	fs              OutputFS
+	formatters      map[string]FormatFunc
+	named           map[string]FormatFunc
+	importFormatter ImportFormatter
+	naming          Naming
+}
+
+// NewGenerator creates a generator
 //
 // buildType is a function for building build.Type to a string
 // plugin is the language plugin
 // config is runtime config of the plugin
-func Init(
+func NewGenerator(
 	buildType BuildTypeFunc,
 	plugin build.Plugin,
 	config build.PluginRuntimeConfig,
-) {
-	// creates context
-	context = NewContext(buildType, plugin, config)
+) *Generator {
+	return &Generator{
+		plugin:     plugin,
+		config:     config,
+		buildType:  buildType,
+		fs:         NewDiskFS(),
+		formatters: make(map[string]FormatFunc),
+		named:      builtinFormatters(),
+	}
+}
+
+// SetBuildFieldType sets function for building type of fields
+func (g *Generator) SetBuildFieldType(buildFieldType BuildFieldTypeFunc) {
+	g.buildFieldType = buildFieldType
+}
+
+// SetOutputFS sets file system which generated files are written to, it's disk by default
+func (g *Generator) SetOutputFS(fs OutputFS) {
+	g.fs = fs
+}
+
+// SetFormatter sets formatter for generated files with extension ext, e.g.
+//
+//	generator.SetFormatter(".go", genutil.GoFmt)
+func (g *Generator) SetFormatter(ext string, formatter FormatFunc) {
+	g.formatters[ext] = formatter
+}
+
+// RegisterFormatter registers a formatter by name which could be used by meta `format`
+// of templates, e.g.
+//
+//	generator.RegisterFormatter("clang-format", clangFormat)
+func (g *Generator) RegisterFormatter(name string, formatter FormatFunc) {
+	g.named[name] = formatter
+}
+
+// SetImportFormatter sets formatter of import blocks which replace `{{imports}}`,
+// formatter of the plugin language is used by default
+func (g *Generator) SetImportFormatter(formatter ImportFormatter) {
+	g.importFormatter = formatter
+}
 
-	funcs = template.FuncMap{
+// SetNaming sets naming conventions applied by template functions typeName,
+// fieldName and constName
+func (g *Generator) SetNaming(naming Naming) {
+	g.naming = naming
+}
+
+// newContext creates a context for generating package pkg
+func (g *Generator) newContext(pkg *build.Package) *Context {
+	ctx := NewContext(g.buildType, g.plugin, g.config)
+	ctx.buildFieldType = g.buildFieldType
+	if g.importFormatter != nil {
+		ctx.importFormatter = g.importFormatter
+	}
+	ctx.naming = g.naming
+	ctx.initWithPkg(pkg)
+	ctx.Pwd = ctx.Plugin.TemplatesDir
+	return ctx
+}
+
+// newFuncs creates template functions bound to ctx
+func newFuncs(ctx *Context) template.FuncMap {
+	return template.FuncMap{
 		// Common functions
 
 		// context returns context
-		"context": func() *Context { return context },
+		"context": func() *Context { return ctx },
 		"debug": func(format string, args ...interface{}) error {
 			log.Debug().Printf(format, args...)
 			return nil
@@ -187,18 +274,44 @@ func Init(
 			log.Error().Printf("Error: %v", err)
 			return err
 		},
+		// errorAt likes error but the error located at position of node in mid source file,
+		// e.g. {{errorAt $field "unsupported type %s" $type}}
+		"errorAt": func(node positioner, format string, args ...interface{}) error {
+			err := fmt.Errorf("%v: %s", node.Position(), fmt.Sprintf(format, args...))
+			log.Error().Printf("Error: %v", err)
+			return err
+		},
 		// include_template includes a template file with `data`
 		// NOTE: includeTemplate ignores meta header
-		"includeTemplate":  includeTemplate,
-		"include_template": includeTemplate,
+		"includeTemplate": func(filename string, data interface{}) (string, error) {
+			return includeTemplate(ctx, filename, data)
+		},
+		"include_template": func(filename string, data interface{}) (string, error) {
+			return includeTemplate(ctx, filename, data)
+		},
 		// include includes a file
 		"include": func(filename string) (string, error) {
-			if !filepath.IsAbs(filename) {
-				filename = filepath.Join(context.Plugin.TemplatesDir, IncludesDir, filename)
+			filename, err := ctx.findInclude(filename, false)
+			if err != nil {
+				return "", err
 			}
 			content, err := ioutil.ReadFile(filename)
 			return string(content), err
 		},
+		// requireImport adds an import to the generated file, it could be called anywhere,
+		// e.g. {{requireImport "fmt"}}, {{requireImport "./demo" "User" "UserList"}}
+		"requireImport": func(path string, names ...string) string {
+			ctx.imports.Add(path, names...)
+			return ""
+		},
+		// imports outputs a placeholder which would be replaced with the import block
+		// formatted for current language after the file generated
+		"imports": func() string { return importsPlaceholder },
+		// typeName, fieldName and constName convert names by naming conventions
+		// of current language, e.g. {{fieldName $field.Name}} is `Id` for go
+		"typeName":  func(name string) string { return ctx.naming.apply(ctx.naming.Type, name) },
+		"fieldName": func(name string) string { return ctx.naming.apply(ctx.naming.Field, name) },
+		"constName": func(name string) string { return ctx.naming.apply(ctx.naming.Const, name) },
 		// isInt check whether the type is an integer
 		"isInt": func(typ string) bool {
 			switch typ {
@@ -213,9 +326,9 @@ func Init(
 		// osenv gets env
 		"osenv": func(key string) string { return os.Getenv(key) },
 		// outdir returns output directory
-		"outdir": func() string { return context.Config.Outdir },
+		"outdir": func() string { return ctx.Config.Outdir },
 		// pwd returns current template file directory
-		"pwd":     func() string { return context.Pwd },
+		"pwd":     func() string { return ctx.Pwd },
 		"slice":   func(values ...interface{}) []interface{} { return values },
 		"valueAt": func(values []interface{}, index int) interface{} { return values[index] },
 		"bareFilename": func(filename string) string {
@@ -256,6 +369,8 @@ func Init(
 			}
 			return false
 		},
+		"add":        func(x, y int) int { return x + y },
+		"underlying": build.Underlying,
 		"toNumber":   toNumber,
 		"toInt":      toInt,
 		"parseInt":   parseInt,
@@ -278,6 +393,7 @@ func Init(
 		"splitN":   func(sep string, n int, s string) []string { return strings.SplitN(s, sep, n) },
 		"split":    func(sep, s string) []string { return strings.Split(s, sep) },
 		"stringAt": func(strs []string, index int) string { return strs[index] },
+		"intAt":    func(ints []int, index int) int { return ints[index] },
 		"string":   func(data interface{}) string { return fmt.Sprintf("%v", data) },
 		"substr": func(startIndex, endIndex int, s string) string {
 			n := len(s)
@@ -337,85 +453,148 @@ func Init(
 	}
 }
 
-// GeneratePackage generates codes for package
-func GeneratePackage(pkg *build.Package) (files map[string]bool, err error) {
-	if context == nil {
-		return nil, errors.Throw("generator not initialized")
+// GeneratePackages generates codes for packages concurrently, number of goroutines
+// is specified by env `jobs`, or number of CPUs by default. Packages are started in
+// order of names, once a package fails no more packages are started, packages being
+// generated are finished and the first error in order of names is returned.
+// The returned files contain only files which are written, files skipped by meta
+// header are excluded.
+func (g *Generator) GeneratePackages(pkgs map[string]*build.Package) (files map[string]bool, err error) {
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	infos, err := OpenTemplatesDir(context.Plugin.Lang, context.Plugin.TemplatesDir)
+	jobs := int(g.config.IntEnv("jobs"))
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failed  bool
+		sem     = make(chan struct{}, jobs)
+		errs    = make([]error, len(names))
+		results = make(map[string]bool)
+	)
+	for i, name := range names {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			break
+		}
+		wg.Add(1)
+		go func(i int, pkg *build.Package) {
+			defer func() {
+				if e := recover(); e != nil {
+					errs[i] = fmt.Errorf("generate package %s: %v", pkg.Name, e)
+				}
+				if errs[i] != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+				<-sem
+				wg.Done()
+			}()
+			files, err := g.GeneratePackage(pkg)
+			errs[i] = err
+			mu.Lock()
+			for file := range files {
+				results[file] = true
+			}
+			mu.Unlock()
+		}(i, pkgs[name])
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// GeneratePackage generates codes for package
+func (g *Generator) GeneratePackage(pkg *build.Package) (files map[string]bool, err error) {
+	ctx := g.newContext(pkg)
+	templates, err := ctx.loadTemplates()
 	if err != nil {
 		return nil, errors.Throw(err.Error())
 	}
-	if len(infos) == 0 {
+	if len(templates) == 0 {
 		log.Warn().
-			String("plugin", context.Plugin.Lang).
+			String("plugin", ctx.Plugin.Lang).
 			Print("no templates found")
 		return nil, nil
 	}
 
-	// sets context.Pkg
-	context.initWithPkg(pkg)
-	context.Pwd = context.Plugin.TemplatesDir
-
-	outdir := context.Config.Outdir
+	outdir := ctx.Config.Outdir
 	// NOTE: environment variable nopkgdir
-	if !context.Config.BoolEnv("nopkgdir") {
+	if !ctx.Config.BoolEnv("nopkgdir") {
 		outdir = filepath.Join(outdir, pkg.Name)
 	}
+	fs := formatFS{OutputFS: g.fs, formatters: g.formatters, named: g.named}
 	constDecls := make([]*GenDecl, 0)
 	files = make(map[string]bool)
-	for _, info := range infos {
-		filename := filepath.Join(context.Plugin.TemplatesDir, info.Name())
-		meta, temp, err := ParseTemplateFile(filename)
-		if err != nil {
-			return files, err
-		}
+	for _, t := range templates {
+		// templates in subdirectories generate files in the same subdirectories of outdir
+		subdir, basename := filepath.Split(t.name)
+		subOutdir := filepath.Join(outdir, subdir)
+		meta, temp := t.meta, t.temp
+		// name of template is the file which the body is parsed from
+		ctx.Pwd = filepath.Dir(temp.Name())
+		ctx.bases = t.bases
 		oldMetaFile := meta.File
 
 		var file io.WriteCloser
-		kind, suffix := ParseTemplateFilename(info.Name())
+		kind, suffix := ParseTemplateFilename(basename)
 
-		// sets context.Root and context.Kind
-		context.Root = temp
-		context.Kind = kind
-		context.Suffix = suffix
-		context.Filename = ""
+		// sets ctx.Root and ctx.Kind
+		ctx.Root = temp
+		ctx.Kind = kind
+		ctx.Suffix = suffix
+		ctx.Filename = ""
 
 		// apply template to specific kind node
 		switch kind {
 		case "package":
 			dftName := pkg.Name + "." + suffix
-			ctxPkg := Package{Package: pkg}
-			if file, err = ApplyMeta(outdir, meta, ctxPkg, dftName); err == nil {
-				err = temp.Execute(file, ctxPkg)
-				files[meta.File] = true
-				file.Close()
+			ctxPkg := Package{Package: pkg, ctx: ctx}
+			if file, err = ApplyMeta(fs, subOutdir, meta, ctxPkg, dftName, ctx.funcs); err == nil {
+				err = ctx.execute(temp, file, ctxPkg)
 				if err != nil {
 					return files, err
 				}
+				for _, name := range outputFiles(file) {
+					files[name] = true
+				}
 			} else {
 				return files, err
 			}
 		case "file":
 			for _, f := range pkg.Files {
-				context.Filename = f.Filename
+				ctx.Filename = f.Filename
 				_, filename := filepath.Split(f.Filename)
 				filename = firstOf(".", filename)
 				dftName := filename + "." + suffix
 				meta.File = oldMetaFile
-				ctxFile := File{File: f}
-				if file, err = ApplyMeta(outdir, meta, ctxFile, dftName); err == nil {
-					files[meta.File] = true
-					err = temp.Execute(file, ctxFile)
-					file.Close()
+				ctxFile := File{File: f, ctx: ctx}
+				if file, err = ApplyMeta(fs, subOutdir, meta, ctxFile, dftName, ctx.funcs); err == nil {
+					err = ctx.execute(temp, file, ctxFile)
 					if err != nil {
 						return files, err
 					}
+					for _, name := range outputFiles(file) {
+						files[name] = true
+					}
 				} else {
 					return files, err
 				}
-				context.Filename = ""
+				ctx.Filename = ""
 			}
 		case "const":
 			if len(constDecls) == 0 {
@@ -429,59 +608,110 @@ func GeneratePackage(pkg *build.Package) (files map[string]bool, err error) {
 			}
 			if len(constDecls) > 0 {
 				meta.File = oldMetaFile
-				if file, err = ApplyMeta(outdir, meta, constDecls, "constants."+suffix); err == nil {
-					files[meta.File] = true
-					err = temp.Execute(file, constDecls)
-					file.Close()
+				if file, err = ApplyMeta(fs, subOutdir, meta, constDecls, "constants."+suffix, ctx.funcs); err == nil {
+					err = ctx.execute(temp, file, constDecls)
 					if err != nil {
 						return files, err
 					}
+					for _, name := range outputFiles(file) {
+						files[name] = true
+					}
 				} else {
 					return files, err
 				}
 			}
 		case "group":
 			for _, f := range pkg.Files {
-				context.Filename = f.Filename
+				ctx.Filename = f.Filename
 				for _, g := range f.Groups {
 					dftName := g.Name + "." + suffix
 					meta.File = oldMetaFile
 					group := NewGroup(f, g)
-					if file, err = ApplyMeta(outdir, meta, group, dftName); err == nil {
-						files[meta.File] = true
-						err = temp.Execute(file, group)
-						file.Close()
+					if file, err = ApplyMeta(fs, subOutdir, meta, group, dftName, ctx.funcs); err == nil {
+						err = ctx.execute(temp, file, group)
 						if err != nil {
 							return files, err
 						}
+						for _, name := range outputFiles(file) {
+							files[name] = true
+						}
 					} else {
 						return files, err
 					}
 				}
-				context.Filename = ""
+				ctx.Filename = ""
+			}
+		case "field":
+			for _, f := range pkg.Files {
+				ctx.Filename = f.Filename
+				for _, b := range f.Beans {
+					// fields of struct and protocol only, enum members and union variants are not fields
+					if b.Kind != lexer.STRUCT.String() && b.Kind != lexer.PROTOCOL.String() {
+						continue
+					}
+					for _, field := range NewBean(f, b).WrapFields() {
+						dftName := b.Name + "_" + strings.Join(field.Names, "_") + "." + suffix
+						meta.File = oldMetaFile
+						if file, err = ApplyMeta(fs, subOutdir, meta, field, dftName, ctx.funcs); err == nil {
+							err = ctx.execute(temp, file, field)
+							if err != nil {
+								return files, err
+							}
+							for _, name := range outputFiles(file) {
+								files[name] = true
+							}
+						} else {
+							return files, err
+						}
+					}
+				}
+				ctx.Filename = ""
+			}
+		case "method":
+			for _, f := range pkg.Files {
+				ctx.Filename = f.Filename
+				for _, b := range f.Beans {
+					for _, method := range NewBean(f, b).WrapMethods() {
+						dftName := b.Name + "_" + method.Name + "." + suffix
+						meta.File = oldMetaFile
+						if file, err = ApplyMeta(fs, subOutdir, meta, method, dftName, ctx.funcs); err == nil {
+							err = ctx.execute(temp, file, method)
+							if err != nil {
+								return files, err
+							}
+							for _, name := range outputFiles(file) {
+								files[name] = true
+							}
+						} else {
+							return files, err
+						}
+					}
+				}
+				ctx.Filename = ""
 			}
 		// beans: enum,struct,protocol,service
 		default:
 			for _, f := range pkg.Files {
-				context.Filename = f.Filename
+				ctx.Filename = f.Filename
 				for _, b := range f.Beans {
 					if b.Kind == kind {
 						dftName := b.Name + "." + suffix
 						meta.File = oldMetaFile
 						bean := NewBean(f, b)
-						if file, err = ApplyMeta(outdir, meta, bean, dftName); err == nil {
-							files[meta.File] = true
-							err = temp.Execute(file, bean)
-							file.Close()
+						if file, err = ApplyMeta(fs, subOutdir, meta, bean, dftName, ctx.funcs); err == nil {
+							err = ctx.execute(temp, file, bean)
 							if err != nil {
 								return files, err
 							}
+							for _, name := range outputFiles(file) {
+								files[name] = true
+							}
 						} else {
 							return files, err
 						}
 					}
 				}
-				context.Filename = ""
+				ctx.Filename = ""
 			}
 		}
 	}
@@ -0,0 +1,140 @@
+package genutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/midlang/mid/src/mid/ast"
+	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
+	"github.com/midlang/mid/src/mid/parser"
+)
+
+// generatePackages generates n packages named pkg0, pkg1, ... by template into memory
+func generatePackages(t *testing.T, n, jobs int, template string) (*MemFS, map[string]bool, error) {
+	t.Helper()
+	dir := t.TempDir()
+	fset := lexer.NewFileSet()
+	pkgs := make(map[string]*ast.Package)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("pkg%d", i)
+		src := fmt.Sprintf("package %s;\nstruct User {\n\tint64 id;\n}\n", name)
+		file, err := parser.ParseFile(fset, name+".mid", []byte(src))
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		pkgs[name] = &ast.Package{
+			Name:    name,
+			Scope:   ast.NewScope(nil),
+			Imports: make(map[string]*ast.Object),
+			Files:   map[string]*ast.File{name + ".mid": file},
+		}
+	}
+	templatesDir := filepath.Join(dir, "templates")
+	if err := os.Mkdir(templatesDir, 0755); err != nil {
+		t.Fatalf("mkdir error: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(templatesDir, "package.txt.temp"), []byte(template), 0644); err != nil {
+		t.Fatalf("write template error: %v", err)
+	}
+
+	builder, err := build.Build(pkgs)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	plugin := build.Plugin{Lang: "txt", Name: "test", TemplatesDir: templatesDir}
+	config := build.PluginRuntimeConfig{
+		Outdir:  "out",
+		Envvars: map[string]string{"jobs": fmt.Sprint(jobs)},
+	}
+	generator := NewGenerator(func(typ build.Type) string { return fmt.Sprint(typ) }, plugin, config)
+	fs := NewMemFS()
+	generator.SetOutputFS(fs)
+	files, err := generator.GeneratePackages(builder.Packages)
+	return fs, files, err
+}
+
+func TestGeneratePackagesConcurrently(t *testing.T) {
+	const template = "package {{.Name}}\n{{range .Files}}{{range .Beans}}{{.Name}}\n{{end}}{{end}}"
+	want, _, err := generatePackages(t, 16, 1, template)
+	if err != nil {
+		t.Fatalf("generate error: %v", err)
+	}
+	if n := len(want.Files()); n != 16 {
+		t.Fatalf("want 16 files, got %d", n)
+	}
+	got, _, err := generatePackages(t, 16, 4, template)
+	if err != nil {
+		t.Fatalf("generate error: %v", err)
+	}
+	if !reflect.DeepEqual(want.Files(), got.Files()) {
+		t.Fatalf("want files %v, got %v", want.Files(), got.Files())
+	}
+	for _, filename := range want.Files() {
+		x, _ := want.ReadFile(filename)
+		y, _ := got.ReadFile(filename)
+		if string(x) != string(y) {
+			t.Errorf("%s: want %q, got %q", filename, x, y)
+		}
+	}
+}
+
+func TestGeneratePackagesFailure(t *testing.T) {
+	// including missing templates fails pkg1 and pkg3
+	const template = "{{if eq .Name \"pkg1\" \"pkg3\"}}{{includeTemplate (printf \"%s.temp\" .Name) .}}{{end}}package {{.Name}}\n"
+	fs, _, err := generatePackages(t, 4, 1, template)
+	if err == nil || !strings.Contains(err.Error(), "pkg1.temp") {
+		t.Fatalf("want error of pkg1, got %v", err)
+	}
+	// packages after the failed one are not started
+	for _, filename := range fs.Files() {
+		if name := filepath.Base(filename); name == "pkg2.txt" || name == "pkg3.txt" {
+			t.Errorf("%s should not be generated after pkg1 failed", filename)
+		}
+	}
+
+	_, _, err = generatePackages(t, 4, 4, template)
+	if err == nil || !strings.Contains(err.Error(), "pkg1.temp") {
+		t.Fatalf("want error of pkg1 first, got %v", err)
+	}
+}
+
+func TestGeneratePackagesSkippedFiles(t *testing.T) {
+	// pkg1 is skipped by skip_if and pkg2 by cond
+	const template = `---
+file:
+  - "{{.Name}}.txt"
+  - "{{.Name}}.md"
+skip_if: eq .Name "pkg1"
+cond: "{{ne .Name \"pkg2\"}}"
+---
+package {{.Name}}
+`
+	fs, files, err := generatePackages(t, 4, 1, template)
+	if err != nil {
+		t.Fatalf("generate error: %v", err)
+	}
+	want := []string{
+		filepath.Join("out", "pkg0", "pkg0.md"),
+		filepath.Join("out", "pkg0", "pkg0.txt"),
+		filepath.Join("out", "pkg3", "pkg3.md"),
+		filepath.Join("out", "pkg3", "pkg3.txt"),
+	}
+	if !reflect.DeepEqual(fs.Files(), want) {
+		t.Fatalf("want written files %v, got %v", want, fs.Files())
+	}
+	got := make([]string, 0, len(files))
+	for name := range files {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want returned files %v, got %v", want, got)
+	}
+}
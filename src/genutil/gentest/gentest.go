@@ -0,0 +1,273 @@
+// Package gentest provides golden file tests for templates.
+//
+// A test generates codes of mid source files into memory and compares them
+// with golden files, e.g.
+//
+//	func TestDefaultTemplates(t *testing.T) {
+//		gentest.Run(t, "testdata/default", gentest.KindOptions("go", "default"), "../../../../testdata/demo.mid")
+//	}
+//
+// Run `go test -update` to rewrite golden files after changing templates.
+package gentest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/midlang/mid/src/genutil"
+	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
+	"github.com/midlang/mid/src/mid/parser"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// outdir is the output directory of generated files in memory
+const outdir = "out"
+
+// Options holds options for generating codes
+type Options struct {
+	// Lang is language of the plugin, e.g. go
+	Lang string
+	// TemplatesDir is directory of templates, e.g. templates/default/go
+	TemplatesDir string
+	// TemplatesRootDir contains template kinds which could be extended, e.g. templates
+	TemplatesRootDir string
+	// BuildType builds type for the language, registered backend of Lang is
+	// used if it's nil
+	BuildType genutil.BuildTypeFunc
+	// BuildFieldType builds type of fields, it's optional
+	BuildFieldType genutil.BuildFieldTypeFunc
+	// Formatters format generated files by extension, e.g. ".go": genutil.GoFmt
+	Formatters map[string]genutil.FormatFunc
+	// ImportPaths are paths for lookuping imports
+	ImportPaths []string
+	// Envvars holds custom environment variables for source files and templates
+	Envvars map[string]string
+	// Types overrides type mappings like `types` of project config
+	Types map[string]string
+	// Extensions are names of builtin extensions, e.g. codec
+	Extensions []string
+}
+
+// templatesRoot is the directory of builtin templates in the repository
+var templatesRoot = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "templates")
+}()
+
+// extensionsRoot is the directory of builtin extensions in the repository
+var extensionsRoot = filepath.Join(templatesRoot, "..", "extensions")
+
+// KindOptions returns options for builtin templates of kind for language lang,
+// e.g. KindOptions("go", "default") for templates/default/go
+func KindOptions(lang, kind string) Options {
+	return Options{
+		Lang:             lang,
+		TemplatesDir:     filepath.Join(templatesRoot, kind, lang),
+		TemplatesRootDir: templatesRoot,
+	}
+}
+
+// Generate parses and builds source files, and generates codes into memory.
+// Keys of the result are slash-separated names of generated files relative to
+// the output directory, e.g. demo/demo.go
+func Generate(opts Options, files ...string) (map[string][]byte, error) {
+	envvars := opts.Envvars
+	if envvars == nil {
+		envvars = make(map[string]string)
+	}
+	fset := lexer.NewFileSet()
+	pkgs, err := parser.ParseFilesWithEnv(fset, parser.Env(envvars), opts.ImportPaths, files)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := build.Build(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// templates include other templates by absolute paths which are joined with `pwd`
+	templatesDir, err := filepath.Abs(opts.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	plugin := build.Plugin{
+		Lang:         opts.Lang,
+		Name:         "gentest",
+		TemplatesDir: templatesDir,
+	}
+	config := build.PluginRuntimeConfig{
+		Outdir:           outdir,
+		Envvars:          envvars,
+		TemplatesRootDir: opts.TemplatesRootDir,
+		Types:            opts.Types,
+	}
+	if len(opts.Extensions) > 0 {
+		config.ExtentionsDir = extensionsRoot
+		if config.Extensions, err = build.LoadExtensions(extensionsRoot, opts.Extensions); err != nil {
+			return nil, err
+		}
+	}
+	generator, err := newGenerator(opts, plugin, config)
+	if err != nil {
+		return nil, err
+	}
+	fs := genutil.NewMemFS()
+	generator.SetOutputFS(fs)
+	for ext, formatter := range opts.Formatters {
+		generator.SetFormatter(ext, formatter)
+	}
+	if _, err := generator.GeneratePackages(builder.Packages); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte)
+	for _, filename := range fs.Files() {
+		name, err := filepath.Rel(outdir, filename)
+		if err != nil {
+			return nil, err
+		}
+		result[filepath.ToSlash(name)], _ = fs.ReadFile(filename)
+	}
+	return result, nil
+}
+
+// newGenerator creates generator by BuildType of opts, or by registered backend if BuildType is nil
+func newGenerator(opts Options, plugin build.Plugin, config build.PluginRuntimeConfig) (*genutil.Generator, error) {
+	if opts.BuildType != nil {
+		generator := genutil.NewGenerator(opts.BuildType, plugin, config)
+		generator.SetBuildFieldType(opts.BuildFieldType)
+		return generator, nil
+	}
+	backend, ok := genutil.LookupBackend(opts.Lang)
+	if !ok {
+		return nil, fmt.Errorf("backend %q not registered", opts.Lang)
+	}
+	return backend.NewGenerator(plugin, config)
+}
+
+// TypeMapper loads type mapper for templates of opts, it's used for creating
+// BuildType and BuildFieldType of language plugins
+func TypeMapper(tb testing.TB, opts Options) *genutil.TypeMapper {
+	tb.Helper()
+	templatesDir, err := filepath.Abs(opts.TemplatesDir)
+	if err != nil {
+		tb.Fatalf("load type mapper: %v", err)
+	}
+	plugin := build.Plugin{Lang: opts.Lang, TemplatesDir: templatesDir}
+	config := build.PluginRuntimeConfig{TemplatesRootDir: opts.TemplatesRootDir, Types: opts.Types}
+	mapper, err := genutil.LoadTypeMapper(plugin, config)
+	if err != nil {
+		tb.Fatalf("load type mapper: %v", err)
+	}
+	return mapper
+}
+
+// Run generates codes of source files and compares them with golden files in
+// directory golden. Golden files are rewritten if tests run with flag -update.
+func Run(t testing.TB, golden string, opts Options, files ...string) {
+	t.Helper()
+	generated, err := Generate(opts, files...)
+	if err != nil {
+		t.Fatalf("generate %v: %v", files, err)
+	}
+	if *update {
+		if err := writeGolden(golden, generated); err != nil {
+			t.Fatalf("update golden files: %v", err)
+		}
+		return
+	}
+	expected, err := readGolden(golden)
+	if err != nil {
+		t.Fatalf("read golden files: %v", err)
+	}
+	for _, name := range sortedNames(generated) {
+		want, ok := expected[name]
+		if !ok {
+			t.Errorf("%s: unexpected generated file, run with -update to accept it", name)
+			continue
+		}
+		if got := generated[name]; !bytes.Equal(got, want) {
+			t.Errorf("%s: generated content differs from golden file%s", name, diff(want, got))
+		}
+	}
+	for _, name := range sortedNames(expected) {
+		if _, ok := generated[name]; !ok {
+			t.Errorf("%s: golden file not generated", name)
+		}
+	}
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readGolden reads all files in directory dir
+func readGolden(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = data
+		return nil
+	})
+	return files, err
+}
+
+// writeGolden replaces directory dir with files
+func writeGolden(dir string, files map[string][]byte) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for name, data := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff returns the first different line of want and got
+func diff(want, got []byte) string {
+	wantLines := bytes.Split(want, []byte("\n"))
+	gotLines := bytes.Split(got, []byte("\n"))
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g []byte
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if !bytes.Equal(w, g) {
+			return fmt.Sprintf(" at line %d:\n\twant: %q\n\tgot:  %q", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package genutil
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gopherd/log"
+)
+
+// importsPlaceholder is output of template function `imports`, it's replaced
+// with the import block after the file generated
+const importsPlaceholder = "\x00mid:imports\x00"
+
+// Import represents an import required by a generated file
+type Import struct {
+	// Path is path of the imported package, module or header, e.g. fmt, ./demo, vector
+	Path string
+	// Names holds aliases of the package in go, or imported names in ts and js
+	Names []string
+}
+
+// ImportSet collects imports required by a generated file, it's safe for concurrent use
+type ImportSet struct {
+	mu      sync.Mutex
+	imports map[string]*Import
+}
+
+// NewImportSet creates an empty ImportSet
+func NewImportSet() *ImportSet {
+	return &ImportSet{imports: make(map[string]*Import)}
+}
+
+// Add adds an import of path with optional names, duplicated imports are merged
+func (set *ImportSet) Add(path string, names ...string) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	imp, ok := set.imports[path]
+	if !ok {
+		imp = &Import{Path: path}
+		set.imports[path] = imp
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		i := sort.SearchStrings(imp.Names, name)
+		if i < len(imp.Names) && imp.Names[i] == name {
+			continue
+		}
+		imp.Names = append(imp.Names, "")
+		copy(imp.Names[i+1:], imp.Names[i:])
+		imp.Names[i] = name
+	}
+}
+
+// Len returns number of imported paths
+func (set *ImportSet) Len() int {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	return len(set.imports)
+}
+
+// Imports returns imports sorted by path
+func (set *ImportSet) Imports() []*Import {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	imports := make([]*Import, 0, len(set.imports))
+	for _, imp := range set.imports {
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].Path < imports[j].Path })
+	return imports
+}
+
+// ImportFormatter formats imports sorted by path to a block of codes
+type ImportFormatter func(imports []*Import) string
+
+// importFormatters holds builtin import formatters by language
+var importFormatters = map[string]ImportFormatter{
+	"go":       FormatGoImports,
+	"cpp":      FormatCppIncludes,
+	"ts":       FormatTSImports,
+	"js":       FormatTSImports,
+	"csharp":   FormatCSharpUsings,
+	"protobuf": FormatProtobufImports,
+}
+
+// FormatGoImports formats imports of go, standard packages are grouped before others, e.g.
+//
+//	import (
+//		"fmt"
+//
+//		"github.com/midlang/mid/x/go/codec"
+//	)
+func FormatGoImports(imports []*Import) string {
+	var std, others []string
+	for _, imp := range imports {
+		lines := &others
+		if !strings.Contains(strings.SplitN(imp.Path, "/", 2)[0], ".") {
+			lines = &std
+		}
+		if len(imp.Names) == 0 {
+			*lines = append(*lines, strconv.Quote(imp.Path))
+		}
+		for _, name := range imp.Names {
+			*lines = append(*lines, name+" "+strconv.Quote(imp.Path))
+		}
+	}
+	switch len(std) + len(others) {
+	case 0:
+		return ""
+	case 1:
+		return "import " + strings.Join(append(std, others...), "")
+	}
+	var buf bytes.Buffer
+	buf.WriteString("import (\n")
+	for _, line := range std {
+		buf.WriteString("\t" + line + "\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, line := range others {
+		buf.WriteString("\t" + line + "\n")
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// FormatCppIncludes formats includes of c++, a path without extension is a
+// system header, e.g. `vector` -> `#include <vector>`, `demo.h` -> `#include "demo.h"`.
+// Paths enclosed in `<>` or `""` are included as they are.
+func FormatCppIncludes(imports []*Import) string {
+	var system, local []string
+	for _, imp := range imports {
+		path := imp.Path
+		switch {
+		case strings.HasPrefix(path, "<"):
+			system = append(system, path)
+		case strings.HasPrefix(path, `"`):
+			local = append(local, path)
+		case filepath.Ext(path) == "":
+			system = append(system, "<"+path+">")
+		default:
+			local = append(local, strconv.Quote(path))
+		}
+	}
+	var lines []string
+	for _, path := range append(system, local...) {
+		lines = append(lines, "#include "+path)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatTSImports formats imports of typescript and javascript modules, names are
+// imported in braces except namespace imports like `* as codec`, e.g.
+//
+//	import { User, UserList } from "./demo";
+func FormatTSImports(imports []*Import) string {
+	var lines []string
+	for _, imp := range imports {
+		path := strconv.Quote(imp.Path)
+		var names []string
+		for _, name := range imp.Names {
+			if strings.HasPrefix(name, "*") {
+				lines = append(lines, "import "+name+" from "+path+";")
+			} else {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			lines = append(lines, "import { "+strings.Join(names, ", ")+" } from "+path+";")
+		} else if len(imp.Names) == 0 {
+			lines = append(lines, "import "+path+";")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatCSharpUsings formats using directives of c#
+func FormatCSharpUsings(imports []*Import) string {
+	var lines []string
+	for _, imp := range imports {
+		lines = append(lines, "using "+imp.Path+";")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatProtobufImports formats imports of protobuf
+func FormatProtobufImports(imports []*Import) string {
+	var lines []string
+	for _, imp := range imports {
+		lines = append(lines, "import "+strconv.Quote(imp.Path)+";")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaceImports replaces the first placeholder in content of generated file
+// filename with import block formatted by format, other placeholders are removed
+func replaceImports(filename string, content []byte, imports *ImportSet, format ImportFormatter) []byte {
+	placeholder := []byte(importsPlaceholder)
+	i := bytes.Index(content, placeholder)
+	if i < 0 {
+		if imports.Len() > 0 {
+			log.Warn().Printf("%s: imports required but placeholder {{imports}} not found", filename)
+		}
+		return content
+	}
+	var block string
+	if format != nil {
+		block = format(imports.Imports())
+	} else if imports.Len() > 0 {
+		log.Warn().Printf("%s: no import formatter for the language", filename)
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(content) + len(block))
+	buf.Write(content[:i])
+	buf.WriteString(block)
+	buf.Write(bytes.Replace(content[i+len(placeholder):], placeholder, nil, -1))
+	return buf.Bytes()
+}
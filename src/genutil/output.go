@@ -0,0 +1,284 @@
+package genutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutputFS is a file system which generated files are written to.
+// It must be safe for concurrent use.
+type OutputFS interface {
+	// Exist reports whether file exists
+	Exist(filename string) bool
+	// ReadFile reads content of file, the error satisfies os.IsNotExist if file not found
+	ReadFile(filename string) ([]byte, error)
+	// WriteFile creates or truncates file and writes data to it
+	WriteFile(filename string, data []byte) error
+}
+
+// FormatFunc formats content of generated file
+type FormatFunc func(filename string, src []byte) ([]byte, error)
+
+// DiskFS writes generated files to disk
+type DiskFS struct{}
+
+// NewDiskFS creates a DiskFS
+func NewDiskFS() DiskFS { return DiskFS{} }
+
+// Exist implements OutputFS.Exist
+func (DiskFS) Exist(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// ReadFile implements OutputFS.ReadFile
+func (DiskFS) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+// WriteFile implements OutputFS.WriteFile
+func (DiskFS) WriteFile(filename string, data []byte) error {
+	dir, _ := filepath.Split(filename)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(filename, data, 0666)
+}
+
+// MemFS holds generated files in memory, it's useful for tests, dry-runs and previews
+type MemFS struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemFS creates an empty MemFS
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string][]byte)}
+}
+
+// Exist implements OutputFS.Exist
+func (fs *MemFS) Exist(filename string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	_, ok := fs.files[filepath.Clean(filename)]
+	return ok
+}
+
+// ReadFile implements OutputFS.ReadFile
+func (fs *MemFS) ReadFile(filename string) ([]byte, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	data, ok := fs.files[filepath.Clean(filename)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// WriteFile implements OutputFS.WriteFile
+func (fs *MemFS) WriteFile(filename string, data []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.files[filepath.Clean(filename)] = append([]byte(nil), data...)
+	return nil
+}
+
+// Files returns sorted names of all files
+func (fs *MemFS) Files() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	names := make([]string, 0, len(fs.files))
+	for name := range fs.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Formats of archive
+const (
+	ArchiveTar = "tar"
+	ArchiveZip = "zip"
+)
+
+// ArchiveFS holds generated files in memory and writes them to an archive by Close
+type ArchiveFS struct {
+	*MemFS
+	w      io.Writer
+	format string
+	root   string
+}
+
+// NewArchiveFS creates an ArchiveFS which writes files to w formatted by format
+// (tar or zip), names of files in archive are relative to root
+func NewArchiveFS(w io.Writer, format, root string) (*ArchiveFS, error) {
+	switch format {
+	case ArchiveTar, ArchiveZip:
+	default:
+		return nil, errors.New("unsupported archive format: " + format)
+	}
+	return &ArchiveFS{
+		MemFS:  NewMemFS(),
+		w:      w,
+		format: format,
+		root:   root,
+	}, nil
+}
+
+// name returns name of file in archive
+func (fs *ArchiveFS) name(filename string) string {
+	if fs.root != "" {
+		if rel, err := filepath.Rel(fs.root, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(filename), "/")
+}
+
+// Close writes all files to the archive
+func (fs *ArchiveFS) Close() error {
+	now := time.Now()
+	switch fs.format {
+	case ArchiveTar:
+		tw := tar.NewWriter(fs.w)
+		for _, filename := range fs.Files() {
+			data, _ := fs.ReadFile(filename)
+			header := &tar.Header{
+				Name:    fs.name(filename),
+				Mode:    0644,
+				Size:    int64(len(data)),
+				ModTime: now,
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if _, err := tw.Write(data); err != nil {
+				return err
+			}
+		}
+		return tw.Close()
+	default:
+		zw := zip.NewWriter(fs.w)
+		for _, filename := range fs.Files() {
+			data, _ := fs.ReadFile(filename)
+			header := &zip.FileHeader{
+				Name:     fs.name(filename),
+				Method:   zip.Deflate,
+				Modified: now,
+			}
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}
+}
+
+// formatFS formats files by extension before writing them to fs
+type formatFS struct {
+	OutputFS
+	formatters map[string]FormatFunc
+	// named holds formatters which could be selected by meta `format`
+	named map[string]FormatFunc
+	// format formats all files if it's not nil
+	format FormatFunc
+}
+
+// WriteFile formats data and writes it to underlying fs, data would be written
+// as it is if failed to format
+func (fs formatFS) WriteFile(filename string, data []byte) error {
+	format := fs.format
+	if format == nil {
+		format = fs.formatters[filepath.Ext(filename)]
+	}
+	if format == nil {
+		return fs.OutputFS.WriteFile(filename, data)
+	}
+	formatted, err := format(filename, data)
+	if err != nil {
+		if werr := fs.OutputFS.WriteFile(filename, data); werr != nil {
+			return werr
+		}
+		return fmt.Errorf("format file %s: %v", filename, err)
+	}
+	return fs.OutputFS.WriteFile(filename, formatted)
+}
+
+// withFormatter returns a fs which formats all files by formatter name instead of extensions
+func withFormatter(fs OutputFS, name string) (OutputFS, error) {
+	named := builtinFormatters()
+	if ffs, ok := fs.(formatFS); ok {
+		fs, named = ffs.OutputFS, ffs.named
+	}
+	format, ok := named[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown formatter %q", name)
+	}
+	if format == nil {
+		return fs, nil
+	}
+	return formatFS{OutputFS: fs, named: named, format: format}, nil
+}
+
+// outputFile buffers content of generated file and writes it to fs by Close
+type outputFile struct {
+	fs       OutputFS
+	filename string
+	append   bool
+	buf      bytes.Buffer
+}
+
+func (f *outputFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+// appendLocks holds locks of files which are appended, so appending to the same
+// file by packages generated concurrently is serialized
+var appendLocks = struct {
+	sync.Mutex
+	m map[string]*sync.Mutex
+}{m: make(map[string]*sync.Mutex)}
+
+// lockAppend locks file for appending and returns the unlock function
+func lockAppend(filename string) func() {
+	filename = filepath.Clean(filename)
+	appendLocks.Lock()
+	mu, ok := appendLocks.m[filename]
+	if !ok {
+		mu = new(sync.Mutex)
+		appendLocks.m[filename] = mu
+	}
+	appendLocks.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (f *outputFile) Close() error {
+	data := f.buf.Bytes()
+	if f.append {
+		defer lockAppend(f.filename)()
+		old, err := f.fs.ReadFile(f.filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		data = append(old, data...)
+	}
+	return f.fs.WriteFile(f.filename, data)
+}
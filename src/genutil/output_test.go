@@ -0,0 +1,161 @@
+package genutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemFS(t *testing.T) {
+	fs := NewMemFS()
+	if fs.Exist("out/a.txt") {
+		t.Fatalf("out/a.txt should not exist")
+	}
+	if _, err := fs.ReadFile("out/a.txt"); !os.IsNotExist(err) {
+		t.Fatalf("want not exist error, got %v", err)
+	}
+	data := []byte("hello")
+	if err := fs.WriteFile("out/./a.txt", data); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	// written data is copied
+	data[0] = 'j'
+	if got, err := fs.ReadFile("out/a.txt"); err != nil || string(got) != "hello" {
+		t.Fatalf("want hello, got %q, %v", got, err)
+	}
+	fs.WriteFile("out/b/c.txt", nil)
+	fs.WriteFile("out/a.txt", []byte("world"))
+	if got, _ := fs.ReadFile("out/a.txt"); string(got) != "world" {
+		t.Errorf("want world after rewriting, got %q", got)
+	}
+	if want := []string{"out/a.txt", "out/b/c.txt"}; !reflect.DeepEqual(fs.Files(), want) {
+		t.Errorf("want files %v, got %v", want, fs.Files())
+	}
+}
+
+// slowFS delays reading, so concurrent appends would lose lines if they're not serialized
+type slowFS struct {
+	*MemFS
+}
+
+func (fs slowFS) ReadFile(filename string) ([]byte, error) {
+	data, err := fs.MemFS.ReadFile(filename)
+	time.Sleep(time.Millisecond)
+	return data, err
+}
+
+func TestAppendConcurrently(t *testing.T) {
+	fs := slowFS{NewMemFS()}
+	const n = 32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f := &outputFile{fs: fs, filename: "out/all.txt", append: true}
+			fmt.Fprintf(f, "line %d\n", i)
+			if err := f.Close(); err != nil {
+				t.Errorf("close error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	data, _ := fs.ReadFile("out/all.txt")
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != n {
+		t.Fatalf("want %d lines, got %d:\n%s", n, len(lines), data)
+	}
+	for i := 0; i < n; i++ {
+		if !bytes.Contains(data, []byte(fmt.Sprintf("line %d\n", i))) {
+			t.Errorf("line %d lost", i)
+		}
+	}
+}
+
+func TestArchiveFS(t *testing.T) {
+	files := map[string]string{
+		"out/demo/demo.go":   "package demo\n",
+		"out/demo/sub/x.txt": "x",
+		"other/y.txt":        "y",
+	}
+	want := map[string]string{
+		"demo/demo.go":   "package demo\n",
+		"demo/sub/x.txt": "x",
+		"other/y.txt":    "y",
+	}
+	for _, format := range []string{ArchiveTar, ArchiveZip} {
+		var buf bytes.Buffer
+		fs, err := NewArchiveFS(&buf, format, "out")
+		if err != nil {
+			t.Fatalf("%s: new archive error: %v", format, err)
+		}
+		for name, content := range files {
+			if err := fs.WriteFile(name, []byte(content)); err != nil {
+				t.Fatalf("%s: write error: %v", format, err)
+			}
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: archive should be written by Close", format)
+		}
+		if err := fs.Close(); err != nil {
+			t.Fatalf("%s: close error: %v", format, err)
+		}
+		got, err := readArchive(format, buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: read archive error: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want files %v, got %v", format, want, got)
+		}
+	}
+	if _, err := NewArchiveFS(ioutil.Discard, "rar", ""); err == nil {
+		t.Errorf("unsupported archive format should be an error")
+	}
+}
+
+// readArchive reads all files of tar or zip archive
+func readArchive(format string, data []byte) (map[string]string, error) {
+	files := make(map[string]string)
+	if format == ArchiveTar {
+		tr := tar.NewReader(bytes.NewReader(data))
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return files, nil
+			} else if err != nil {
+				return nil, err
+			}
+			content, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			files[header.Name] = string(content)
+		}
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[file.Name] = string(content)
+	}
+	return files, nil
+}
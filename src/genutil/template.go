@@ -8,11 +8,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/gopherd/log"
 	"github.com/mkideal/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -21,6 +24,13 @@ const (
 
 	// TemplateFileSuffix is the template filename suffix could be recognized
 	TemplateFileSuffix = ".temp"
+
+	// ExtendsKey is the meta key for declaring base template kind, e.g. `extends: beans`
+	ExtendsKey = "extends"
+
+	// CommonDir contains templates shared by all template kinds of a language,
+	// e.g. templates/_common/go
+	CommonDir = "_common"
 )
 
 // Template wraps template.Template
@@ -43,7 +53,41 @@ func (temp *Template) Lookup(name string) *Template {
 	return NewTemplate(sub)
 }
 
+// TemplateFiles returns template files in directory dir and its subdirectories,
+// returned filenames are relative to dir. Directory `includes` and directories
+// whose names begin with `_` or `.` are skipped.
+func TemplateFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && (name == IncludesDir || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, TemplateFileSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Throw(fmt.Sprintf("open templates directory %s error: %v", dir, err))
+	}
+	return files, nil
+}
+
 // OpenTemplatesDir opens a directory for getting all files and directories
+//
+// Deprecated: use TemplateFiles instead, OpenTemplatesDir ignores subdirectories
 func OpenTemplatesDir(lang, dir string) ([]os.FileInfo, error) {
 	// open templates directory
 	fs, err := os.Open(dir)
@@ -68,128 +112,470 @@ func OpenTemplatesDir(lang, dir string) ([]os.FileInfo, error) {
 	return templates, nil
 }
 
+// Keys of template meta header
+const (
+	// MetaFile is the output file, or a list of output files which are generated by the same content
+	MetaFile = "file"
+	// MetaCond skips generating if its value is false
+	MetaCond = "cond"
+	// MetaNotExist generates the file only if it doesn't exist
+	MetaNotExist = "notexist"
+	// MetaAppend appends generated content to the file
+	MetaAppend = "append"
+	// MetaSkipIf skips generating if the expression (or any of the expressions) is true
+	MetaSkipIf = "skip_if"
+	// MetaFormat is name of formatter for output files, e.g. gofmt, none
+	MetaFormat = "format"
+
+	// MetaCustomPrefix is the prefix of custom keys, e.g. `x-owner: mkideal`
+	MetaCustomPrefix = "x-"
+)
+
+// metaKeys holds keys of meta header which could be recognized,
+// values are whether the key accepts a list
+var metaKeys = map[string]bool{
+	MetaFile:     true,
+	MetaCond:     false,
+	MetaNotExist: false,
+	MetaAppend:   false,
+	MetaSkipIf:   true,
+	MetaFormat:   false,
+	ExtendsKey:   false,
+	// descriptive keys
+	"author": false,
+	"date":   false,
+	"desc":   false,
+}
+
 // TemplateMeta represents meta information of a template file
 type TemplateMeta struct {
-	File                 string
-	nativeValues, Values map[string]string
+	File string
+	// Files holds all output files, File is the first one
+	Files []string
+	// Values holds executed values of meta header except `file` as strings
+	Values map[string]string
+	// nativeValues holds values decoded from meta header, a value is a string,
+	// a scalar (bool, int, float) or a list of them
+	nativeValues map[string]interface{}
+}
+
+// validate reports unknown keys and values with unsupported types
+func (meta *TemplateMeta) validate() error {
+	keys := make([]string, 0, len(meta.nativeValues))
+	for k := range meta.nativeValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		isList, ok := metaKeys[k]
+		if !ok && !strings.HasPrefix(k, MetaCustomPrefix) {
+			return fmt.Errorf("unknown meta key %q", k)
+		}
+		switch v := meta.nativeValues[k].(type) {
+		case []interface{}:
+			if !isList {
+				return fmt.Errorf("meta %s: unexpected list value", k)
+			}
+			for _, x := range v {
+				if !isMetaScalar(x) {
+					return fmt.Errorf("meta %s: unexpected value %v", k, x)
+				}
+			}
+		default:
+			if !isMetaScalar(v) {
+				return fmt.Errorf("meta %s: unexpected value %v", k, v)
+			}
+		}
+	}
+	return nil
+}
+
+func isMetaScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, int, int64, uint64, float64, nil:
+		return true
+	}
+	return false
 }
 
-// ParseTemplateFile parses template file
-func ParseTemplateFile(filename string) (*TemplateMeta, *Template, error) {
+// ParseTemplateFile parses template file with template functions funcs.
+// NOTE: `extends` in meta header is resolved by generator instead of ParseTemplateFile,
+// and keys of meta header are not validated.
+func ParseTemplateFile(filename string, funcs template.FuncMap) (*TemplateMeta, *Template, error) {
+	meta, body, err := readTemplateFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	temp, err := template.New(filename).Funcs(funcs).Parse(body)
+	if err != nil {
+		err = fmt.Errorf("ParseTemplateFile %s: %v", filename, err)
+		return nil, nil, err
+	}
+	return meta, NewTemplate(temp), nil
+}
+
+// readTemplateFile reads meta header and body of template file
+func readTemplateFile(filename string) (*TemplateMeta, string, error) {
 	meta := &TemplateMeta{
 		Values:       make(map[string]string),
-		nativeValues: make(map[string]string),
+		nativeValues: make(map[string]interface{}),
 	}
-	// parse template file meta header
+	// parse template file meta header which is a YAML block
 	// e.g.
 	//
 	// ---
-	// file: {{.Name}}.go
+	// file: "{{.Name}}.go"
+	// skip_if: eq .Kind "service"
 	// ---
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		err = fmt.Errorf("ParseTemplateFile %s: %v", filename, err)
-		return nil, nil, err
+		return nil, "", err
 	}
 	advance, token, _ := bufio.ScanLines(data, true)
 	const metaHeaderFlag = "---"
 	if string(token) == metaHeaderFlag {
-		ended := false
-		line := 1
+		var (
+			ended  = false
+			header = advance
+			end    int
+		)
 		for advance < len(data) {
 			tmp, tok, _ := bufio.ScanLines(data[advance:], true)
-			advance += tmp
 			if tmp == 0 {
 				break
 			}
 			if string(tok) == metaHeaderFlag {
 				ended = true
+				end = advance
+				advance += tmp
 				break
 			}
-			line++
-			kv := strings.SplitN(string(tok), ":", 2)
-			if len(kv) != 2 {
-				err = fmt.Errorf("%s:%d: not a key value pair split by `:`", filename, line)
-				return nil, nil, err
-			}
-			kv[0] = strings.TrimSpace(kv[0])
-			meta.nativeValues[kv[0]] = kv[1]
+			advance += tmp
 		}
 		if !ended {
 			err = fmt.Errorf("%s: unexpected meta header end", filename)
-			return nil, nil, err
+			return nil, "", err
+		}
+		if err := yaml.Unmarshal(data[header:end], &meta.nativeValues); err != nil {
+			// unquoted template values like `file: {{.Name}}.go` are not valid YAML,
+			// so the header is parsed as lines of key value pairs split by `:`
+			values, lerr := parseMetaLines(strings.Split(string(data[header:end]), "\n"))
+			if lerr != nil {
+				err = fmt.Errorf("%s: invalid meta header: %v", filename, err)
+				return nil, "", err
+			}
+			meta.nativeValues = values
+		}
+		if meta.nativeValues == nil {
+			meta.nativeValues = make(map[string]interface{})
 		}
 		data = data[advance:]
 	}
-	temp := template.New(filename)
-	temp = temp.Funcs(funcs)
-	temp, err = temp.Parse(string(data))
-	if err != nil {
-		err = fmt.Errorf("ParseTemplateFile %s: %v", filename, err)
-		return nil, nil, err
+	return meta, string(data), nil
+}
+
+// parseMetaLines parses lines of meta header in which each line is a key value pair split by `:`
+func parseMetaLines(lines []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: not a key value pair split by `:`", i+2)
+		}
+		values[strings.TrimSpace(kv[0])] = kv[1]
 	}
-	return meta, NewTemplate(temp), err
+	return values, nil
 }
 
-// ApplyMeta creates a target file by the template meta
-func ApplyMeta(outdir string, meta *TemplateMeta, data interface{}, dftName string) (io.WriteCloser, error) {
-	// execute template for meta
-	values := make(map[string]string)
-	for k, v := range meta.nativeValues {
-		temp := template.New(k)
-		temp = temp.Funcs(funcs)
-		temp, err := temp.Parse(v)
+// kindTemplate represents a template file of current template kind or inherited from base kinds
+type kindTemplate struct {
+	// name is filename relative to directory of template kind
+	name string
+	// dir is directory of template kind which the file belongs to
+	dir  string
+	meta *TemplateMeta
+	temp *Template
+	// bases holds directories of base kinds in order of the inheritance chain
+	bases []string
+}
+
+// loadTemplates loads templates of current template kind. A template which declares
+// `extends: <kind>` in meta header is parsed on top of the template with the same
+// name in base kind, so it could override `block`s of the base template by `define`.
+// Templates of base kinds which are not overridden are inherited.
+func (ctx *Context) loadTemplates() ([]*kindTemplate, error) {
+	var (
+		templates []*kindTemplate
+		loaded    = make(map[string]bool)
+		dirs      = []string{ctx.Plugin.TemplatesDir}
+		visited   = map[string]bool{ctx.Plugin.TemplatesDir: true}
+	)
+	for i := 0; i < len(dirs); i++ {
+		names, err := TemplateFiles(dirs[i])
 		if err != nil {
-			log.Error().Printf("apply meta error: %v", err)
 			return nil, err
 		}
-		var buf bytes.Buffer
-		if err = temp.Execute(&buf, data); err != nil {
-			log.Error().Printf("apply meta error: %v", err)
-			return nil, err
+		for _, name := range names {
+			if loaded[name] {
+				continue
+			}
+			loaded[name] = true
+			t, err := ctx.loadTemplate(dirs[i], name, nil)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, t)
+			for _, base := range t.bases {
+				if !visited[base] {
+					visited[base] = true
+					dirs = append(dirs, base)
+				}
+			}
 		}
-		v = strings.TrimSpace(buf.String())
-		values[k] = v
-		log.Debug().Printf("meta key value pair: <%s,%s>", k, v)
 	}
-	meta.Values = values
+	return templates, nil
+}
 
-	// pick `file` value to meta.File
-	if value, ok := meta.Values["file"]; ok {
-		meta.File = value
-		delete(meta.Values, "file")
+// loadTemplate parses template file name in directory dir of template kind and templates it extends,
+// chain holds directories of derived kinds for detecting circular inheritance
+func (ctx *Context) loadTemplate(dir, name string, chain []string) (*kindTemplate, error) {
+	filename := filepath.Join(dir, name)
+	for _, derived := range chain {
+		if derived == dir {
+			return nil, fmt.Errorf("%s: circular extends of template kind %s", filename, dir)
+		}
+	}
+	meta, body, err := readTemplateFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := meta.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	base := strings.TrimSpace(fmt.Sprint(meta.nativeValues[ExtendsKey]))
+	if meta.nativeValues[ExtendsKey] == nil {
+		base = ""
+	}
+	delete(meta.nativeValues, ExtendsKey)
+	if base == "" {
+		temp, err := template.New(filename).Funcs(ctx.funcs).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("ParseTemplateFile %s: %v", filename, err)
+		}
+		return &kindTemplate{name: name, dir: dir, meta: meta, temp: NewTemplate(temp)}, nil
+	}
+
+	baseDir, err := ctx.kindDir(base)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	parent, err := ctx.loadTemplate(baseDir, name, append(chain, dir))
+	if err != nil {
+		return nil, err
+	}
+	// values of meta header override values of base template
+	for k, v := range meta.nativeValues {
+		parent.meta.nativeValues[k] = v
+	}
+	// definitions override blocks of base template
+	temp, err := parent.temp.New(filename).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("ParseTemplateFile %s: %v", filename, err)
 	}
-	if meta.File == "" {
-		meta.File = dftName
+	root := parent.temp
+	if temp.Tree != nil && !parse.IsEmptyTree(temp.Tree.Root) {
+		// body of derived template replaces body of base template
+		root = NewTemplate(temp)
 	}
+	return &kindTemplate{
+		name:  name,
+		dir:   dir,
+		meta:  parent.meta,
+		temp:  root,
+		bases: append([]string{baseDir}, parent.bases...),
+	}, nil
+}
 
-	if !filepath.IsAbs(meta.File) {
-		meta.File = filepath.Join(outdir, meta.File)
+// kindDir returns directory of template kind for current language, the kind is
+// searched beside current template kind and in templates root directory
+func (ctx *Context) kindDir(kind string) (string, error) {
+	var dirs []string
+	for _, root := range ctx.templateRoots() {
+		dir := filepath.Join(root, kind, ctx.Plugin.Lang)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		dirs = append(dirs, dir)
 	}
+	return "", fmt.Errorf("template kind %s not found in %s", kind, strings.Join(dirs, string(os.PathListSeparator)))
+}
 
-	_, err := os.Stat(meta.File)
-	if meta.Values["cond"] != "false" && (err != nil || meta.Values["notexist"] != "true") {
-		dir, _ := filepath.Split(meta.File)
-		if dir != "" && dir != "." {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				log.Error().Printf("mkdir %s error: %v", dir, err)
-				return nil, err
+// ApplyMeta creates target files in fs by the template meta, values of meta are
+// executed with template functions funcs. Content of files is written to fs
+// when the returned writer closed.
+func ApplyMeta(fs OutputFS, outdir string, meta *TemplateMeta, data interface{}, dftName string, funcs template.FuncMap) (io.WriteCloser, error) {
+	// execute template for meta
+	var (
+		values = make(map[string]string)
+		files  []string
+		skip   bool
+	)
+	for k, v := range meta.nativeValues {
+		switch k {
+		case MetaFile:
+			for _, x := range metaList(v) {
+				file, err := executeMetaValue(k, x, data, funcs)
+				if err != nil {
+					return nil, err
+				}
+				if file != "" {
+					files = append(files, file)
+				}
 			}
+			log.Debug().Printf("meta key value pair: <%s,%v>", k, files)
+			continue
+		case MetaSkipIf:
+			for _, x := range metaList(v) {
+				if expr, ok := x.(string); ok && !strings.Contains(expr, "{{") {
+					x = "{{" + expr + "}}"
+				}
+				value, err := executeMetaValue(k, x, data, funcs)
+				if err != nil {
+					return nil, err
+				}
+				if value == "true" {
+					skip = true
+					break
+				}
+			}
+			v = skip
+		}
+		value, err := executeMetaValue(k, v, data, funcs)
+		if err != nil {
+			return nil, err
 		}
-		flags := os.O_CREATE | os.O_WRONLY
-		if meta.Values["append"] == "true" {
-			flags |= os.O_APPEND
+		values[k] = value
+		log.Debug().Printf("meta key value pair: <%s,%s>", k, value)
+	}
+	meta.Values = values
+
+	if len(files) == 0 {
+		if meta.File == "" {
+			files = []string{dftName}
 		} else {
-			flags |= os.O_TRUNC
+			files = []string{meta.File}
 		}
-		file, err := os.OpenFile(meta.File, flags, 0666)
-		if err != nil {
-			log.Error().Printf("open file %s error: %v", meta.File, err)
+	}
+	for i := range files {
+		if !filepath.IsAbs(files[i]) {
+			files[i] = filepath.Join(outdir, files[i])
+		}
+	}
+	meta.File = files[0]
+	meta.Files = files
+
+	if skip || meta.Values[MetaCond] == "false" {
+		return discard, nil
+	}
+	if name := meta.Values[MetaFormat]; name != "" {
+		var err error
+		if fs, err = withFormatter(fs, name); err != nil {
 			return nil, err
 		}
-		return file, err
 	}
-	return discard, nil
+	var writers multiWriteCloser
+	for _, file := range files {
+		if meta.Values[MetaNotExist] == "true" && fs.Exist(file) {
+			continue
+		}
+		writers = append(writers, &outputFile{
+			fs:       fs,
+			filename: file,
+			append:   meta.Values[MetaAppend] == "true",
+		})
+	}
+	switch len(writers) {
+	case 0:
+		return discard, nil
+	case 1:
+		return writers[0], nil
+	default:
+		return writers, nil
+	}
+}
+
+// metaList returns elements of v if v is a list, otherwise returns v as a list
+func metaList(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+// executeMetaValue executes value of meta key k with data if it's a string,
+// other values are formatted as they are
+func executeMetaValue(k string, v interface{}, data interface{}, funcs template.FuncMap) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return "", nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	temp, err := template.New(k).Funcs(funcs).Parse(s)
+	if err != nil {
+		log.Error().Printf("apply meta error: %v", err)
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = temp.Execute(&buf, data); err != nil {
+		log.Error().Printf("apply meta error: %v", err)
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// outputFiles returns names of files written by w which is returned by ApplyMeta,
+// files skipped by `skip_if`, `cond` or `notexist` are not included
+func outputFiles(w io.WriteCloser) []string {
+	switch w := w.(type) {
+	case *outputFile:
+		return []string{w.filename}
+	case multiWriteCloser:
+		var files []string
+		for _, x := range w {
+			files = append(files, outputFiles(x)...)
+		}
+		return files
+	}
+	return nil
+}
+
+// multiWriteCloser writes content to all writers
+type multiWriteCloser []io.WriteCloser
+
+func (ws multiWriteCloser) Write(p []byte) (int, error) {
+	for _, w := range ws {
+		if n, err := w.Write(p); err != nil {
+			return n, err
+		}
+	}
+	return len(p), nil
+}
+
+func (ws multiWriteCloser) Close() error {
+	var err error
+	for _, w := range ws {
+		if e := w.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 type discardWriter struct{}
@@ -0,0 +1,150 @@
+package genutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/midlang/mid/src/mid/build"
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// TypesFile is the file of type map shipped with templates, e.g. templates/_common/go/types.yaml
+const TypesFile = "types.yaml"
+
+// Keys of container types in TypeMap, patterns of containers could reference
+// element type by `$T`, key and value types by `$K` and `$V`, size of array by `$N`,
+// e.g. `vector: "[]$T"`, `map: "map[$K]$V"`
+const (
+	TypeKeyArray  = "array"
+	TypeKeyVector = "vector"
+	TypeKeySet    = "set"
+	TypeKeyMap    = "map"
+	TypeKeyOMap   = "omap"
+)
+
+// TypeMap maps builtin types (e.g. int, bytes, time) and container types
+// (array, vector, set, map, omap) of mid to types of a language
+type TypeMap map[string]string
+
+// LoadTypeMap loads type map from a YAML file
+func LoadTypeMap(filename string) (TypeMap, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	types := make(TypeMap)
+	if err := yaml.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("load type map %s: %v", filename, err)
+	}
+	return types, nil
+}
+
+// TypeMapper maps builtin and container types of mid to types of a language by TypeMap,
+// other types like struct and function types are built by language plugins
+type TypeMapper struct {
+	Lang  string
+	Types TypeMap
+}
+
+// NewTypeMapper creates a TypeMapper for language lang
+func NewTypeMapper(lang string, types TypeMap) *TypeMapper {
+	if types == nil {
+		types = make(TypeMap)
+	}
+	return &TypeMapper{Lang: lang, Types: types}
+}
+
+// LoadTypeMapper loads type maps for the plugin, latter overrides former:
+//
+//  1. `_common/<lang>/types.yaml` in templates root directory
+//  2. `_common/<lang>/types.yaml` beside the template kind
+//  3. `types.yaml` in the template kind directory
+//  4. `config.Types` which is specified by project config
+func LoadTypeMapper(plugin build.Plugin, config build.PluginRuntimeConfig) (*TypeMapper, error) {
+	var files []string
+	roots := templateRoots(plugin, config)
+	for i := len(roots) - 1; i >= 0; i-- {
+		files = append(files, filepath.Join(roots[i], CommonDir, plugin.Lang, TypesFile))
+	}
+	files = append(files, filepath.Join(plugin.TemplatesDir, TypesFile))
+
+	m := NewTypeMapper(plugin.Lang, nil)
+	loaded := make(map[string]bool)
+	for _, filename := range files {
+		if loaded[filename] {
+			continue
+		}
+		loaded[filename] = true
+		types, err := LoadTypeMap(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		m.Override(types)
+	}
+	m.Override(config.Types)
+	return m, nil
+}
+
+// Override overrides mappings by types
+func (m *TypeMapper) Override(types map[string]string) {
+	for k, v := range types {
+		m.Types[k] = v
+	}
+}
+
+// lookup returns mapped type of key, it panics if key not found
+func (m *TypeMapper) lookup(key string) string {
+	value, ok := m.Types[key]
+	if !ok {
+		panic(fmt.Sprintf("no type mapping for `%s` in language %s, add it to %s", key, m.Lang, TypesFile))
+	}
+	return value
+}
+
+// MapType maps builtin or container type typ, element types of containers are built by elem.
+// It returns false if typ is neither a builtin type nor a container type.
+func (m *TypeMapper) MapType(typ build.Type, elem BuildTypeFunc) (string, bool) {
+	switch t := typ.(type) {
+	case *build.BasicType:
+		if _, ok := lexer.LookupType(t.Name); !ok {
+			panic("type `" + t.Name + "` not a builtin type")
+		}
+		return m.lookup(t.Name), true
+	case *build.ArrayType:
+		size, ok := build.IntFromExpr(t.Size)
+		if !ok {
+			panic("array.Size not an integer")
+		}
+		return m.expand(TypeKeyArray, "$T", elem(t.T), "$N", size), true
+	case *build.VectorType:
+		return m.expand(TypeKeyVector, "$T", elem(t.T)), true
+	case *build.SetType:
+		return m.expand(TypeKeySet, "$T", elem(t.T)), true
+	case *build.MapType:
+		key := TypeKeyMap
+		if t.Ordered {
+			key = TypeKeyOMap
+		}
+		return m.expand(key, "$K", elem(t.K), "$V", elem(t.V)), true
+	default:
+		return "", false
+	}
+}
+
+// expand replaces placeholders in pattern of container type key
+func (m *TypeMapper) expand(key string, oldnew ...string) string {
+	return strings.NewReplacer(oldnew...).Replace(m.lookup(key))
+}
+
+// FieldType returns type of field declared by tag `<lang>.type`, e.g. `go.type:"MyType"`
+func (m *TypeMapper) FieldType(field *build.Field) (string, bool) {
+	return field.Tag.Lookup(m.Lang + ".type")
+}
@@ -2,6 +2,7 @@ package genutil
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/midlang/mid/src/mid/build"
 )
@@ -9,6 +10,7 @@ import (
 // Package wraps build.Package
 type Package struct {
 	*build.Package
+	ctx *Context
 }
 
 // Depcrated API, use `Gen' instead
@@ -18,6 +20,10 @@ func (pkg Package) GenerateDeclsBySubTemplates() (string, error) {
 
 // Gen generates file by predefined sub-template `T_const`, `T_group`, `T_<kind>`
 func (pkg Package) Gen() (string, error) {
+	context := pkg.ctx
+	if context == nil {
+		return "", errors.New("package " + pkg.Name + " has no generating context")
+	}
 	buf := new(bytes.Buffer)
 
 	if temp := context.Root.Lookup("T_const"); temp != nil {
@@ -68,6 +74,7 @@ func (pkg Package) Gen() (string, error) {
 type File struct {
 	*build.File
 	groups map[string]*build.Group
+	ctx    *Context
 }
 
 func (f *File) FindGroup(name string) *build.Group {
@@ -100,6 +107,10 @@ func (f File) GenerateDeclsBySubTemplates() (string, error) {
 
 // Gen generates file by predefined sub-template `T_const`, `T_<kind>`
 func (f File) Gen() (string, error) {
+	context := f.ctx
+	if context == nil {
+		return "", errors.New("file " + f.Filename + " has no generating context")
+	}
 	buf := new(bytes.Buffer)
 
 	if temp := context.Root.Lookup("T_const"); temp != nil {
@@ -164,6 +175,55 @@ func (bean *Bean) BuildExtends(ctx *Context) []string {
 	return strs
 }
 
+// NestedBeans gets nested beans of bean, names of nested beans are prefixed
+// with name of bean and sep if sep is not empty, e.g. Outer_Inner for sep "_"
+func (bean *Bean) NestedBeans(sep string) []*Bean {
+	beans := make([]*Bean, 0, len(bean.Nested))
+	for _, nested := range bean.Nested {
+		if sep != "" {
+			copied := *nested
+			copied.Name = bean.Name + sep + nested.Name
+			nested = &copied
+		}
+		beans = append(beans, &Bean{Bean: nested, File: bean.File})
+	}
+	return beans
+}
+
+// Field wraps build.Field with the bean which declares it, it's data of `field` templates
+type Field struct {
+	*build.Field
+	// Index is index of the field in fields of the bean
+	Index int
+	Bean  *Bean
+}
+
+// WrapFields returns wrapped fields of bean
+func (bean *Bean) WrapFields() []*Field {
+	fields := make([]*Field, 0, len(bean.Fields))
+	for i, field := range bean.Fields {
+		fields = append(fields, &Field{Field: field, Index: i, Bean: bean})
+	}
+	return fields
+}
+
+// Method wraps build.Method with the service which has it, it's data of `method` templates
+type Method struct {
+	*build.Method
+	// Index is index of the method in methods of the service
+	Index int
+	Bean  *Bean
+}
+
+// WrapMethods returns wrapped methods of service bean
+func (bean *Bean) WrapMethods() []*Method {
+	methods := make([]*Method, 0, len(bean.Methods))
+	for i, method := range bean.Methods {
+		methods = append(methods, &Method{Method: method, Index: i, Bean: bean})
+	}
+	return methods
+}
+
 // AddTag is a chain function for adding tag
 func (bean *Bean) AddTag(key, value string, field *build.Field) *build.Field {
 	return bean.addTag(key, value, field, true)
@@ -15,11 +15,11 @@ type Node interface {
 // Node
 // - Field,FieldList,Method,MethodList,Comment,CommentGroup,File,Package
 // - Expr
-//   - BadExpr,Ident,BasicLit
+//   - BadExpr,Ident,BasicLit,EnvExpr
 // - Type
-//   - BasicType,ArrayType,MapType,VectorType,StructType
+//   - BasicType,ArrayType,MapType,VectorType,SetType,StructType
 // - Decl
-//   - GenDecl,BeanDecl
+//   - GenDecl,BeanDecl,GroupDecl,TypeDecl
 // - Spec
 //   - ImportSpec,ConstSpec
 
@@ -34,11 +34,13 @@ type Expr interface {
 func (*BadExpr) exprNode()    {}
 func (*Ident) exprNode()      {}
 func (*BasicLit) exprNode()   {}
+func (*EnvExpr) exprNode()    {}
 func (*BasicType) exprNode()  {}
 func (*StructType) exprNode() {}
 func (*MapType) exprNode()    {}
 func (*ArrayType) exprNode()  {}
 func (*VectorType) exprNode() {}
+func (*SetType) exprNode()    {}
 func (*FuncType) exprNode()   {}
 
 type BadExpr struct {
@@ -74,6 +76,17 @@ func (bl *BasicLit) IsString() (bool, string) {
 	return true, s
 }
 
+// environment variable node: $NAME or ${NAME:-default}
+type EnvExpr struct {
+	Dollar     lexer.Pos
+	Name       string
+	Default    string    // default value if HasDefault
+	HasDefault bool      // whether `:-default` present
+	Value      *BasicLit // resolved value or nil
+}
+
+func (ee *EnvExpr) Begin() lexer.Pos { return ee.Dollar }
+
 //-----------
 // Type node
 //-----------
@@ -87,6 +100,7 @@ func (*BasicType) typeNode()  {}
 func (*ArrayType) typeNode()  {}
 func (*MapType) typeNode()    {}
 func (*VectorType) typeNode() {}
+func (*SetType) typeNode()    {}
 func (*StructType) typeNode() {}
 func (*FuncType) typeNode()   {}
 
@@ -94,6 +108,7 @@ func (t *BasicType) Ident() *Ident  { return t.Name }
 func (t *ArrayType) Ident() *Ident  { return nil }
 func (t *MapType) Ident() *Ident    { return nil }
 func (t *VectorType) Ident() *Ident { return nil }
+func (t *SetType) Ident() *Ident    { return nil }
 func (t *StructType) Ident() *Ident { return t.Name }
 func (t *FuncType) Ident() *Ident   { return nil }
 
@@ -115,13 +130,14 @@ type ArrayType struct {
 
 func (at *ArrayType) Begin() lexer.Pos { return at.Pos }
 
-// map<K,V>
+// map<K,V> or omap<K,V>
 type MapType struct {
 	Pos     lexer.Pos
 	Less    lexer.Pos // <
 	K       Type
 	V       Type
 	Greater lexer.Pos // >
+	Ordered bool      // omap
 }
 
 func (mt *MapType) Begin() lexer.Pos { return mt.Pos }
@@ -136,10 +152,21 @@ type VectorType struct {
 
 func (vt *VectorType) Begin() lexer.Pos { return vt.Pos }
 
+// set<T>
+type SetType struct {
+	Pos     lexer.Pos
+	Less    lexer.Pos // <
+	T       Type
+	Greater lexer.Pos // >
+}
+
+func (st *SetType) Begin() lexer.Pos { return st.Pos }
+
 // struct/protocol
 type StructType struct {
-	Package *Ident // package or nil
+	Package *Ident   // package, outer bean or nil
 	Name    *Ident
+	Nested  []*Ident // names of nested beans after Name, e.g. C in a.B.C
 }
 
 func (st *StructType) Begin() lexer.Pos {
@@ -153,6 +180,11 @@ type FuncType struct {
 	Func   lexer.Pos
 	Params *FieldList // arguments
 	Result Type       // return type or nil
+	// Results holds named or multiple results declared as `(T1 a, T2 b)`, or nil
+	Results      *FieldList
+	Throws       Type // error enum declared by `throws`, or nil
+	ClientStream bool // a parameter marked by `stream`
+	ServerStream bool // the result marked by `stream`
 }
 
 func (ft *FuncType) Begin() lexer.Pos {
@@ -205,6 +237,7 @@ func (*BadDecl) declNode()   {}
 func (*GenDecl) declNode()   {}
 func (*BeanDecl) declNode()  {}
 func (*GroupDecl) declNode() {}
+func (*TypeDecl) declNode()  {}
 
 type BadDecl struct {
 	From lexer.Pos
@@ -227,13 +260,14 @@ func (gd *GenDecl) Begin() lexer.Pos { return gd.TokPos }
 
 // bean declaration node: struct or protocol
 type BeanDecl struct {
-	Kind    string // struct or protocol
+	Kind    string // struct, protocol, service, enum or union
 	Pos     lexer.Pos
 	Doc     *CommentGroup
 	Name    *Ident
 	Extends []Type
 	Tag     *BasicLit
 	Fields  *FieldList
+	Decls   []*BeanDecl // nested declarations
 }
 
 func (bd *BeanDecl) Begin() lexer.Pos { return bd.Pos }
@@ -248,6 +282,18 @@ type GroupDecl struct {
 
 func (gd *GroupDecl) Begin() lexer.Pos { return gd.Pos }
 
+// type declaration node: type Name Type
+type TypeDecl struct {
+	Pos     lexer.Pos
+	Doc     *CommentGroup
+	Name    *Ident
+	Type    Type // underlying type
+	Tag     *BasicLit
+	Comment *CommentGroup
+}
+
+func (td *TypeDecl) Begin() lexer.Pos { return td.Pos }
+
 //-----------
 // spec node
 //-----------
@@ -340,6 +386,7 @@ type File struct {
 	Imports    []*ImportSpec   // imports in this file
 	Unresolved []*Ident        // unresolved identifiers in this file
 	Comments   []*CommentGroup // list of all comments in the source file
+	Source     *lexer.File     // used to compute positions of nodes; or nil
 }
 
 func (f *File) Begin() lexer.Pos { return f.Package }
@@ -449,6 +496,8 @@ func Walk(node Node, visitor Visitor) Visitor {
 		return visitor
 	case *BasicLit:
 		return visitor
+	case *EnvExpr:
+		return visitor
 	case *BasicType:
 		visitor = walkNodes(visitor, n.Name)
 	case *ArrayType:
@@ -457,15 +506,27 @@ func Walk(node Node, visitor Visitor) Visitor {
 		visitor = walkNodes(visitor, n.K, n.V)
 	case *VectorType:
 		visitor = walkNodes(visitor, n.T)
+	case *SetType:
+		visitor = walkNodes(visitor, n.T)
 	case *StructType:
 		visitor = walkNodes(visitor, n.Package, n.Name)
+		visitor = walkIdents(visitor, n.Nested)
 	case *FuncType:
 		visitor = walkNodes(visitor, n.Params, n.Result)
+		if n.Results != nil {
+			visitor = walkNodes(visitor, n.Results)
+		}
+		visitor = walkNodes(visitor, n.Throws)
 	case *GenDecl:
 		visitor = walkNodes(visitor, n.Doc)
 		visitor = walkSpecs(visitor, n.Specs)
 	case *BeanDecl:
 		visitor = walkNodes(visitor, n.Doc, n.Name, n.Fields)
+		for _, decl := range n.Decls {
+			visitor = walkNodes(visitor, decl)
+		}
+	case *TypeDecl:
+		visitor = walkNodes(visitor, n.Doc, n.Name, n.Type, n.Tag, n.Comment)
 	case *ImportSpec:
 		visitor = walkNodes(visitor, n.Doc, n.Name, n.Package, n.Comment)
 	case *ConstSpec:
@@ -88,6 +88,8 @@ func (obj *Object) Begin() lexer.Pos {
 		return d.Begin()
 	case *BeanDecl:
 		return d.Begin()
+	case *TypeDecl:
+		return d.Begin()
 	case *Scope:
 		// nothing to do
 	}
@@ -72,17 +72,36 @@ func Build(pkgs map[string]*ast.Package) (*Builder, error) {
 			}
 		}
 	}
-	for _, pkg := range pkgs {
+	for path, pkg := range pkgs {
 		builtPkg := BuildPackage(pkg)
+		builtPkg.Path = path
 		builder.Packages[pkg.Name] = builtPkg
 		builder.SortedPackages = append(builder.SortedPackages, builtPkg)
 	}
 	sort.Slice(builder.SortedPackages, func(i, j int) bool {
 		return builder.SortedPackages[i].Name < builder.SortedPackages[j].Name
 	})
+	if err := resolveTypes(builder); err != nil {
+		return nil, err
+	}
+	if err := flattenServices(builder); err != nil {
+		return nil, err
+	}
+	if err := checkInheritance(builder); err != nil {
+		return nil, err
+	}
 	return builder, nil
 }
 
+// Lookup finds bean by id, e.g. pkg.Name or pkg.Outer.Inner
+func (builder *Builder) Lookup(id ObjectId) *Bean {
+	pkg := builder.Packages[id.Package()]
+	if pkg == nil {
+		return nil
+	}
+	return pkg.FindBean(id.Name())
+}
+
 func (builder *Builder) Encode() string {
 	if builder.encodedString != "" {
 		return builder.encodedString
@@ -101,5 +120,12 @@ func (builder *Builder) Decode(s string) error {
 	if err != nil {
 		return err
 	}
-	return gob.NewDecoder(bytes.NewBuffer(data)).Decode(builder)
+	if err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(builder); err != nil {
+		return err
+	}
+	// pointers are not shared after decoding, so packages are linked again
+	for i, pkg := range builder.SortedPackages {
+		builder.SortedPackages[i] = builder.Packages[pkg.Name]
+	}
+	return resolveTypes(builder)
 }
@@ -41,6 +41,10 @@ const (
 	ServiceFront   = "service_front"
 	ServiceBack    = "service_back"
 	AfterService   = "after_service"
+	BeforeType     = "before_type"
+	AfterType      = "after_type"
+	BeforeUnion    = "before_union"
+	AfterUnion     = "after_union"
 
 	// Extension config filename
 	ExtConfigFilename = "ext.json"
@@ -48,7 +52,7 @@ const (
 
 func IsValidKind(kind string) bool {
 	switch kind {
-	case "package", "file", "const", "enum", "struct", "protocol", "service":
+	case "package", "file", "const", "enum", "struct", "protocol", "service", "type", "union":
 		return true
 	default:
 		return false
@@ -82,7 +86,11 @@ func (pos EmbeddedPosition) IsValid() bool {
 		BeforeService,
 		ServiceFront,
 		ServiceBack,
-		AfterService:
+		AfterService,
+		BeforeType,
+		AfterType,
+		BeforeUnion,
+		AfterUnion:
 	default:
 		return false
 	}
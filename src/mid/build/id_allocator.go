@@ -4,25 +4,45 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
+	"math"
 	"math/rand"
-	"os"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/midlang/mid/src/mid/lexer"
 )
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+// Kinds used to allocate ids for members of beans
+const (
+	MethodKind = "method" // methods of services
+	FieldKind  = "field"  // fields of structs, protocols and unions, ids are unique in the bean
+	MemberKind = "member" // members of enums, ids are unique in the enum
+)
+
+// isScopedKind reports whether ids of kind are unique in beans rather than globally
+func isScopedKind(kind string) bool {
+	return kind == FieldKind || kind == MemberKind
+}
+
 type BeanIdAllocator interface {
-	// Allocate returns a integer as id of key
-	Allocate(key string) int
+	// Allocate returns a integer as id of key, kind is the kind of bean, e.g. struct
+	Allocate(kind, key string) int
+	// Pin reserves id for key, it should be called before Allocate.
+	// Pinned ids override ids recorded before for the key and are never allocated to other keys,
+	// it returns an error if id is out of range of kind or recorded for other keys.
+	Pin(kind, key string, id int) error
 	// Output outputs all key-id pairs to writer w or outputs to default writer of allocator if w is nil
 	Output(w io.Writer) error
+	// Close releases the allocator, e.g. unlocks the id file
+	Close() error
 }
 
 type IdPair struct {
@@ -42,97 +62,608 @@ func SplitBeanKey(key string) (pkgName, beanName string) {
 	return "", key
 }
 
+// JoinFieldKey returns key of field or enum member, e.g. pkg.User#name
+func JoinFieldKey(beanKey, fieldName string) string {
+	return beanKey + "#" + fieldName
+}
+
+// IdScope returns the scope in which id of key should be unique:
+// key of bean for fields and enum members, empty for others
+func IdScope(key string) string {
+	key = strings.TrimPrefix(key, RetiredKeyPrefix)
+	if index := strings.LastIndex(key, "#"); index >= 0 {
+		return key[:index]
+	}
+	return ""
+}
+
+// NewBeanIdAllocator creates a BeanIdAllocator by name, supported allocators:
+//
+//	file        random increasing ids recorded in file
+//	sequential  sequential ids recorded in file
+//	hash        hash of key in range, collisions resolved by linear probing
+//
+// See ParseIdAllocatorOptions for options.
 func NewBeanIdAllocator(name, opts string) (BeanIdAllocator, error) {
+	options, err := ParseIdAllocatorOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
 	switch name {
 	case "file":
-		return NewFileBeanIdAllocator(opts)
+		return newFileBeanIdAllocator(options, func() int { return 1 + rand.Intn(5) })
+	case "sequential":
+		return newFileBeanIdAllocator(options, func() int { return 1 })
+	case "hash":
+		return newHashBeanIdAllocator(options)
 	default:
 		return nil, errors.New("unsupported bean id allocator: " + name)
 	}
 }
 
-// fileBeanIdAllocator implements BeanIdAllocator
-type fileBeanIdAllocator struct {
-	filename string
-	perm     os.FileMode
-	idMap    map[string]int
-	ids      []IdPair
-	maxId    int
+// NewFileBeanIdAllocator creates an allocator which allocates increasing ids with random steps
+func NewFileBeanIdAllocator(filename string) (BeanIdAllocator, error) {
+	return NewBeanIdAllocator("file", filename)
 }
 
-func NewFileBeanIdAllocator(filename string) (BeanIdAllocator, error) {
-	allocator := &fileBeanIdAllocator{
-		filename: filename,
-		idMap:    make(map[string]int),
+// IdRange represents a closed interval of ids
+type IdRange struct {
+	Min, Max int
+}
+
+func (r IdRange) String() string { return fmt.Sprintf("%d-%d", r.Min, r.Max) }
+
+// Contains reports whether id in range r
+func (r IdRange) Contains(id int) bool { return id >= r.Min && id <= r.Max }
+
+// ParseIdRange parses range formatted like `min-max`
+func ParseIdRange(s string) (IdRange, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return IdRange{}, errors.New("invalid id range " + s + ", expect min-max")
 	}
-	if err := allocator.readInit(); err != nil {
-		return nil, err
+	var (
+		r   IdRange
+		err error
+	)
+	if r.Min, err = strconv.Atoi(strings.TrimSpace(bounds[0])); err != nil {
+		return IdRange{}, errors.New("invalid id range " + s + ": " + bounds[0] + " is not an integer")
 	}
-	return allocator, nil
+	if r.Max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+		return IdRange{}, errors.New("invalid id range " + s + ": " + bounds[1] + " is not an integer")
+	}
+	if r.Min <= 0 || r.Min > r.Max {
+		return IdRange{}, errors.New("invalid id range " + s)
+	}
+	return r, nil
 }
 
-// Allocate allocates id for key if key not found and returns allocated id
-// Found id returned if key found
-func (allocator *fileBeanIdAllocator) Allocate(key string) int {
-	if id, found := allocator.idMap[key]; found {
-		return id
+// IdRanges holds ranges of ids for kinds and packages
+type IdRanges struct {
+	Default IdRange
+	// Kinds holds ranges keyed by kind, e.g. struct
+	Kinds map[string]IdRange
+	// Packages holds ranges keyed by package name or package name and kind, e.g. demo or demo.struct
+	Packages map[string]IdRange
+}
+
+// Lookup returns range of kind for key, in order of package and kind, package, kind and default.
+// Ranges of package and default range are not used by fields and enum members.
+func (ranges IdRanges) Lookup(kind, key string) IdRange {
+	pkg := key
+	if index := strings.Index(key, "."); index >= 0 {
+		pkg = key[:index]
+	}
+	if r, ok := ranges.Packages[pkg+"."+kind]; ok {
+		return r
+	}
+	if isScopedKind(kind) {
+		if r, ok := ranges.Kinds[kind]; ok {
+			return r
+		}
+		return IdRange{Min: 1, Max: math.MaxInt32}
+	}
+	if r, ok := ranges.Packages[pkg]; ok {
+		return r
+	}
+	if r, ok := ranges.Kinds[kind]; ok {
+		return r
+	}
+	return ranges.Default
+}
+
+// Allows reports whether id can be allocated to key of kind, it returns the range of key
+func (ranges IdRanges) Allows(kind, key string, id int) (IdRange, bool) {
+	r := ranges.Lookup(kind, key)
+	if !r.Contains(id) {
+		return r, false
+	}
+	_, reserved := ranges.reservedBy(kind, r, id)
+	return r, !reserved
+}
+
+// reservedBy returns the narrower range in r which contains id. Ids in narrower ranges
+// are reserved for beans of these ranges. Ranges of fields and enum members reserve nothing.
+func (ranges IdRanges) reservedBy(kind string, r IdRange, id int) (IdRange, bool) {
+	if isScopedKind(kind) {
+		return IdRange{}, false
+	}
+	reserves := func(x IdRange) bool {
+		return x != r && x.Contains(id) && r.Contains(x.Min) && r.Contains(x.Max)
+	}
+	if reserves(ranges.Default) {
+		return ranges.Default, true
+	}
+	for k, x := range ranges.Kinds {
+		if !isScopedKind(k) && reserves(x) {
+			return x, true
+		}
+	}
+	for k, x := range ranges.Packages {
+		if index := strings.Index(k, "."); (index < 0 || !isScopedKind(k[index+1:])) && reserves(x) {
+			return x, true
+		}
+	}
+	return IdRange{}, false
+}
+
+// IdAllocatorOptions represents options of BeanIdAllocator
+type IdAllocatorOptions struct {
+	Filename string
+	Format   string
+	Ranges   IdRanges
+}
+
+// ParseIdAllocatorOptions parses comma separated options of allocator, e.g.
+//
+//	ids.txt,format=json,range=1-99999,kind.service=100000-199999,pkg.demo.struct=200000-299999
+//
+// options:
+//
+//	file=<filename>         the file records ids, a leading option without `=` is also the filename.
+//	                        It's required by allocators file and sequential
+//	format=text|json|yaml   format of file, inferred from extension of filename by default
+//	range=<min>-<max>       default range of ids
+//	kind.<kind>=<min>-<max> range of ids for beans of kind, kind `method` for methods of services,
+//	                        `field` for fields and `member` for enum members
+//	pkg.<pkg>=<min>-<max>   range of ids for beans of package
+//	pkg.<pkg>.<kind>=<min>-<max>
+func ParseIdAllocatorOptions(name, opts string) (IdAllocatorOptions, error) {
+	options := IdAllocatorOptions{
+		Ranges: IdRanges{
+			Default:  IdRange{Min: 1, Max: math.MaxInt32},
+			Kinds:    make(map[string]IdRange),
+			Packages: make(map[string]IdRange),
+		},
+	}
+	for i, opt := range strings.Split(opts, ",") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			if i == 0 {
+				options.Filename = opt
+				continue
+			}
+			return options, errors.New("invalid option of id allocator: " + opt)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch {
+		case key == "file":
+			options.Filename = value
+		case key == "format":
+			options.Format = value
+		case key == "range" || strings.HasPrefix(key, "kind.") || strings.HasPrefix(key, "pkg."):
+			r, err := ParseIdRange(value)
+			if err != nil {
+				return options, err
+			}
+			if key == "range" {
+				options.Ranges.Default = r
+			} else if strings.HasPrefix(key, "kind.") {
+				options.Ranges.Kinds[strings.TrimPrefix(key, "kind.")] = r
+			} else {
+				options.Ranges.Packages[strings.TrimPrefix(key, "pkg.")] = r
+			}
+		default:
+			return options, errors.New("unknown option of id allocator: " + key)
+		}
+	}
+	if options.Filename == "" && name != "hash" {
+		return options, errors.New("missing filename of id allocator " + name)
+	}
+	return options, nil
+}
+
+// idSlot identifies an id in scope
+type idSlot struct {
+	scope string
+	id    int
+}
+
+func slotOf(key string, id int) idSlot {
+	return idSlot{scope: IdScope(key), id: id}
+}
+
+// idTable records key-id pairs for allocators
+type idTable struct {
+	idMap  map[string]int
+	owners map[idSlot]string
+	pinned map[string]bool
+	ranges IdRanges
+	file   *IdFile
+	err    error
+}
+
+func newIdTable(options IdAllocatorOptions) (idTable, error) {
+	table := idTable{
+		idMap:  make(map[string]int),
+		owners: make(map[idSlot]string),
+		pinned: make(map[string]bool),
+		ranges: options.Ranges,
+	}
+	if options.Filename == "" {
+		return table, nil
+	}
+	file, err := OpenIdFile(options.Filename, options.Format)
+	if err != nil {
+		return table, err
+	}
+	table.file = file
+	for _, pair := range file.Pairs {
+		if owner, found := table.owners[slotOf(pair.Key, pair.Id)]; found {
+			file.Close()
+			return table, fmt.Errorf("%s: duplicated id %d of %s and %s", file.Filename, pair.Id, pair.Key, owner)
+		}
+		if id, found := table.idMap[pair.Key]; found && !IsRetiredKey(pair.Key) {
+			file.Close()
+			return table, fmt.Errorf("%s: duplicated key %s with ids %d and %d", file.Filename, pair.Key, id, pair.Id)
+		}
+		table.add(pair.Key, pair.Id)
+	}
+	return table, nil
+}
+
+// add records id of key, the id recorded before is released unless key is retired
+func (table *idTable) add(key string, id int) {
+	if old, found := table.idMap[key]; found && !IsRetiredKey(key) && table.owners[slotOf(key, old)] == key {
+		delete(table.owners, slotOf(key, old))
+	}
+	table.idMap[key] = id
+	table.owners[slotOf(key, id)] = key
+}
+
+// pin reserves id for key, id must be in range of kind and not owned by other keys
+func (table *idTable) pin(kind, key string, id int) error {
+	if id <= 0 {
+		return fmt.Errorf("pinned id %d of %s is not positive", id, key)
+	}
+	if r, ok := table.ranges.Allows(kind, key, id); !ok {
+		return fmt.Errorf("pinned id %d of %s %s out of range %v", id, kind, key, r)
+	}
+	if old, found := table.idMap[key]; found && table.pinned[key] && old != id {
+		return fmt.Errorf("%s pinned with different ids %d and %d", key, old, id)
+	}
+	if owner, found := table.owners[slotOf(key, id)]; found && owner != key {
+		if table.pinned[owner] {
+			return fmt.Errorf("pinned id %d of %s conflicts with %s", id, key, owner)
+		}
+		return fmt.Errorf("pinned id %d of %s is recorded for %s", id, key, owner)
+	}
+	table.pinned[key] = true
+	table.add(key, id)
+	return nil
+}
+
+// fail records the first error of allocation, which reported by Output
+func (table *idTable) fail(kind, key string, r IdRange) int {
+	if table.err == nil {
+		table.err = fmt.Errorf("no id available in range %v for %s %s", r, kind, key)
 	}
-	allocator.maxId += 1 + rand.Intn(5)
-	id := allocator.maxId
-	allocator.idMap[key] = id
-	allocator.ids = append(allocator.ids, IdPair{Key: key, Id: id})
-	return id
+	return 0
 }
 
-// Output outputs key-id pairs to writer w or outputs to file allocator.filename if w is nil
-func (allocator *fileBeanIdAllocator) Output(w io.Writer) error {
-	if len(allocator.ids) == 0 {
+// output outputs key-id pairs ordered by id to writer w or to id file if w is nil
+func (table *idTable) output(w io.Writer) error {
+	if table.err != nil {
+		return table.err
+	}
+	if w == nil && table.file == nil {
 		return nil
 	}
+	pairs := make([]IdPair, 0, len(table.owners))
+	for slot, key := range table.owners {
+		pairs = append(pairs, IdPair{Key: key, Id: slot.id})
+	}
 	if w == nil {
-		file, err := os.OpenFile(allocator.filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, allocator.perm)
-		if err != nil {
-			return err
+		if len(pairs) == 0 && len(table.file.Pairs) == 0 {
+			return nil
 		}
-		defer file.Close()
-		w = file
+		table.file.Pairs = pairs
+		return table.file.Write()
 	}
-	sort.Slice(allocator.ids, func(i, j int) bool { return allocator.ids[i].Id < allocator.ids[j].Id })
-	for _, pair := range allocator.ids {
-		fmt.Fprintf(w, "%s=%d\n", pair.Key, pair.Id)
+	SortIdPairs(pairs)
+	format := IdFormatText
+	if table.file != nil {
+		format = table.file.Format
 	}
-	return nil
+	return WriteIdPairs(w, format, pairs)
+}
+
+func (table *idTable) Pin(kind, key string, id int) error { return table.pin(kind, key, id) }
+
+func (table *idTable) Output(w io.Writer) error { return table.output(w) }
+
+func (table *idTable) Close() error {
+	if table.file == nil {
+		return nil
+	}
+	return table.file.Close()
+}
+
+// fileBeanIdAllocator implements BeanIdAllocator, it allocates increasing ids
+type fileBeanIdAllocator struct {
+	idTable
+	step func() int
 }
 
-// readInit reads file to initialize allocator
-func (allocator *fileBeanIdAllocator) readInit() error {
-	info, err := os.Stat(allocator.filename)
+func newFileBeanIdAllocator(options IdAllocatorOptions, step func() int) (*fileBeanIdAllocator, error) {
+	table, err := newIdTable(options)
 	if err != nil {
-		if os.IsNotExist(err) {
-			allocator.perm = 0666
-			return nil
+		return nil, err
+	}
+	return &fileBeanIdAllocator{
+		idTable: table,
+		step:    step,
+	}, nil
+}
+
+// Allocate allocates id greater than all ids in range for key if key not found and returns allocated id
+// Found id returned if key found
+func (allocator *fileBeanIdAllocator) Allocate(kind, key string) int {
+	if id, found := allocator.idMap[key]; found {
+		return id
+	}
+	var (
+		r      = allocator.ranges.Lookup(kind, key)
+		maxId  = r.Min - 1
+		ranges = allocator.ranges
+		scope  = IdScope(key)
+	)
+	for slot := range allocator.owners {
+		if slot.scope == scope && r.Contains(slot.id) && slot.id > maxId {
+			if _, reserved := ranges.reservedBy(kind, r, slot.id); !reserved {
+				maxId = slot.id
+			}
 		}
-		return err
 	}
-	if info.IsDir() {
-		return errors.New(allocator.filename + " is not a regular file")
+	id := maxId + allocator.step()
+	for id <= r.Max {
+		if x, reserved := ranges.reservedBy(kind, r, id); reserved {
+			id = x.Max + 1
+		} else if _, found := allocator.owners[idSlot{scope, id}]; found {
+			id++
+		} else {
+			allocator.add(key, id)
+			return id
+		}
 	}
-	allocator.perm = info.Mode()
-	file, err := os.Open(allocator.filename)
+	return allocator.fail(kind, key, r)
+}
+
+// hashBeanIdAllocator implements BeanIdAllocator, it allocates id by hash of key,
+// so the same key always gets the same id unless the id is used by other keys.
+// Collisions are resolved by linear probing in range.
+type hashBeanIdAllocator struct {
+	idTable
+}
+
+func newHashBeanIdAllocator(options IdAllocatorOptions) (*hashBeanIdAllocator, error) {
+	table, err := newIdTable(options)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return &hashBeanIdAllocator{idTable: table}, nil
+}
+
+// Allocate allocates id by hash if key not found and returns allocated id.
+// It returns 0 if no id available in range, the error reported by Output.
+func (allocator *hashBeanIdAllocator) Allocate(kind, key string) int {
+	if id, found := allocator.idMap[key]; found {
+		return id
+	}
+	var (
+		r    = allocator.ranges.Lookup(kind, key)
+		size = uint64(r.Max-r.Min) + 1
+		h    = fnv.New64a()
+	)
+	h.Write([]byte(key))
+	var (
+		id     = r.Min + int(h.Sum64()%size)
+		ranges = allocator.ranges
+		scope  = IdScope(key)
+	)
+	for tries := uint64(0); tries < size; {
+		if x, reserved := ranges.reservedBy(kind, r, id); reserved {
+			tries += uint64(x.Max-id) + 1
+			id = x.Max + 1
+		} else if _, found := allocator.owners[idSlot{scope, id}]; found {
+			tries++
+			id++
+		} else {
+			allocator.add(key, id)
+			return id
+		}
+		if id > r.Max {
+			id = r.Min
+		}
 	}
-	defer file.Close()
-	idMap, err := ReadBeanIds(file, "=")
+	return allocator.fail(kind, key, r)
+}
+
+// IdEntry represents a bean, method, field or enum member which should be allocated an id
+type IdEntry struct {
+	Kind string
+	Key  string
+	// Bean is the bean or the bean which the method or field belongs to
+	Bean   *Bean
+	Method *Method
+	Field  *Field
+}
+
+// Position returns position of entry in source file
+func (entry IdEntry) Position() lexer.Position {
+	if entry.Field != nil {
+		return entry.Field.Position()
+	}
+	if entry.Method != nil {
+		return entry.Method.Position()
+	}
+	return entry.Bean.Position()
+}
+
+// IdEntries returns beans (and methods of services) which kind contained in kinds,
+// and fields or enum members if kinds contains `field` or `member`,
+// in order of packages, files and declarations
+func IdEntries(builder *Builder, kinds map[string]bool) []IdEntry {
+	var (
+		entries []IdEntry
+		collect func(pkg string, list []*Bean)
+	)
+	collect = func(pkg string, list []*Bean) {
+		for _, bean := range list {
+			key := JoinBeanKey(pkg, bean.QualifiedName())
+			if kinds[bean.Kind] {
+				entries = append(entries, IdEntry{
+					Kind: bean.Kind,
+					Key:  key,
+					Bean: bean,
+				})
+				for _, method := range bean.Methods {
+					entries = append(entries, IdEntry{
+						Kind:   MethodKind,
+						Key:    method.Key(),
+						Bean:   bean,
+						Method: method,
+					})
+				}
+			}
+			kind := FieldKind
+			if bean.Kind == lexer.ENUM.String() {
+				kind = MemberKind
+			}
+			if kinds[kind] && bean.Kind != lexer.SERVICE.String() {
+				for _, field := range bean.Fields {
+					name := ""
+					if len(field.Names) == 1 {
+						name = field.Names[0]
+					}
+					entries = append(entries, IdEntry{
+						Kind:  kind,
+						Key:   JoinFieldKey(key, name),
+						Bean:  bean,
+						Field: field,
+					})
+				}
+			}
+			collect(pkg, bean.Nested)
+		}
+	}
+	for _, pkg := range builder.SortedPackages {
+		for _, file := range pkg.Files {
+			collect(pkg.Name, file.Beans)
+		}
+	}
+	return entries
+}
+
+// PinnedId returns id pinned by tag `id` of bean or field,
+// e.g. `struct User `id:"100"` {...}` or `int64 uid `id:"1"`;`
+func (entry IdEntry) PinnedId() (id int, ok bool, err error) {
+	var tag Tag
+	switch {
+	case entry.Field != nil:
+		tag = entry.Field.Tag
+	case entry.Method != nil:
+		return 0, false, nil
+	default:
+		tag = entry.Bean.Tag
+	}
+	value, ok := tag.Lookup("id")
+	if !ok {
+		return 0, false, nil
+	}
+	id, err = strconv.Atoi(strings.TrimSpace(value))
 	if err != nil {
-		return err
+		return 0, false, errorAt(entry, "invalid id %q of %s", value, entry.Key)
+	}
+	return id, true, nil
+}
+
+// memberValue returns positive value of enum member which is specified explicitly
+func (entry IdEntry) memberValue() (int, bool) {
+	if entry.Kind != MemberKind {
+		return 0, false
 	}
-	allocator.idMap = idMap
-	for key, id := range idMap {
-		if len(allocator.ids) == 0 || allocator.maxId < id {
-			allocator.maxId = id
+	value, ok := ParseIntFromExpr(entry.Field.Default)
+	return value, ok && value > 0
+}
+
+// AllocateIds allocates ids for entries returned by IdEntries.
+// Ids pinned by tag `id` and positive values of enum members are reserved first,
+// so omitted values of enum members never collide with values specified explicitly.
+// Then other entries are allocated in order of packages, files and declarations.
+// Ids of beans are copied to StructType of types which reference them.
+func AllocateIds(allocator BeanIdAllocator, builder *Builder, kinds map[string]bool) error {
+	entries := IdEntries(builder, kinds)
+	values := make(map[idSlot]bool)
+	for _, entry := range entries {
+		if entry.Field != nil && len(entry.Field.Names) != 1 {
+			return errorAt(entry, "%s of %s must have exactly one name to be allocated an id", entry.Kind, entry.Bean.Name)
+		}
+		id, ok, err := entry.PinnedId()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// members with the same value share the id pinned by the first one
+			if id, ok = entry.memberValue(); !ok || values[slotOf(entry.Key, id)] {
+				continue
+			}
+			values[slotOf(entry.Key, id)] = true
+		}
+		if err := allocator.Pin(entry.Kind, entry.Key, id); err != nil {
+			return errorAt(entry, "%v", err)
+		}
+	}
+	for _, entry := range entries {
+		id := allocator.Allocate(entry.Kind, entry.Key)
+		switch {
+		case entry.Field != nil:
+			entry.Field.Id = id
+			if entry.Kind == MemberKind && !entry.Field.hasValue() && id != 0 {
+				// omitted value of enum member is the allocated id
+				entry.Field.Default = &BasicLit{Kind: lexer.INT, Value: strconv.Itoa(id)}
+			}
+		case entry.Method != nil:
+			entry.Method.Id = id
+		default:
+			entry.Bean.Id = id
+		}
+	}
+	resolveIds(builder)
+	return nil
+}
+
+// CheckEnumValues checks values of enum members, value of member could be omitted
+// only if the member is allocated an id with kind `member`
+func CheckEnumValues(builder *Builder) error {
+	for _, entry := range IdEntries(builder, map[string]bool{MemberKind: true}) {
+		if !entry.Field.hasValue() {
+			return errorAt(entry, "value of enum member %s is omitted but no id allocated for it", entry.Key)
 		}
-		allocator.ids = append(allocator.ids, IdPair{Key: key, Id: id})
 	}
 	return nil
 }
@@ -140,8 +671,21 @@ func (allocator *fileBeanIdAllocator) readInit() error {
 // ReadBeanIds read key-id pairs from reader.
 // Each line contains one key-id pair seperated by `sep`.
 func ReadBeanIds(reader io.Reader, sep string) (map[string]int, error) {
+	pairs, err := readIdLines(reader, sep)
+	if err != nil {
+		return nil, err
+	}
+	idMap := make(map[string]int)
+	for _, pair := range pairs {
+		idMap[pair.Key] = pair.Id
+	}
+	return idMap, nil
+}
+
+// readIdLines reads key-id pairs in order of lines from reader
+func readIdLines(reader io.Reader, sep string) ([]IdPair, error) {
 	var (
-		idMap   = make(map[string]int)
+		pairs   []IdPair
 		advance int
 		token   []byte
 	)
@@ -172,7 +716,7 @@ func ReadBeanIds(reader io.Reader, sep string) (map[string]int, error) {
 		if err != nil {
 			return nil, errors.New(lineno + value + " is not an integer")
 		}
-		idMap[key] = id
+		pairs = append(pairs, IdPair{Key: key, Id: id})
 	}
-	return idMap, nil
+	return pairs, nil
 }
@@ -0,0 +1,293 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of id file
+const (
+	IdFormatText = "text" // lines of key=id
+	IdFormatJSON = "json" // object of key-id pairs
+	IdFormatYAML = "yaml" // mapping of key-id pairs
+)
+
+// RetiredKeyPrefix is the prefix of keys of retired ids. Retired ids are
+// kept in id file and never allocated again.
+const RetiredKeyPrefix = "-"
+
+// RetiredKey returns key of retired id of key, e.g. -pkg.User@12. The id is
+// contained in the key, so a key retired more than once keeps all its ids.
+func RetiredKey(key string, id int) string {
+	return RetiredKeyPrefix + key + "@" + strconv.Itoa(id)
+}
+
+// IsRetiredKey reports whether key is a key of retired id
+func IsRetiredKey(key string) bool {
+	return strings.HasPrefix(key, RetiredKeyPrefix)
+}
+
+// IdFile represents a file which records allocated ids. The file is locked
+// by OpenIdFile until Close called, so it's safe to be shared by processes.
+// The lock file <Filename>.lock exists only while the file is locked.
+type IdFile struct {
+	Filename string
+	Format   string
+	// Pairs holds key-id pairs in order of file
+	Pairs []IdPair
+
+	perm os.FileMode
+	lock *os.File
+}
+
+// OpenIdFile locks and reads id file, it's ok if file not found.
+// Format is inferred from extension of filename if format is empty.
+func OpenIdFile(filename, format string) (*IdFile, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".json":
+			format = IdFormatJSON
+		case ".yaml", ".yml":
+			format = IdFormatYAML
+		default:
+			format = IdFormatText
+		}
+	}
+	switch format {
+	case IdFormatText, IdFormatJSON, IdFormatYAML:
+	default:
+		return nil, errors.New("unsupported format of id file: " + format)
+	}
+	f := &IdFile{
+		Filename: filename,
+		Format:   format,
+		perm:     0666,
+	}
+	lock, err := lockPath(filename + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %v", filename, err)
+	}
+	f.lock = lock
+	if err := f.read(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *IdFile) read() error {
+	info, err := os.Stat(f.Filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		return errors.New(f.Filename + " is not a regular file")
+	}
+	f.perm = info.Mode()
+	file, err := os.Open(f.Filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	f.Pairs, err = ReadIdPairs(file, f.Format)
+	if err != nil {
+		return fmt.Errorf("%s: %v", f.Filename, err)
+	}
+	return nil
+}
+
+// Write writes pairs ordered by scope and id to a temporary file and renames it to f.Filename
+func (f *IdFile) Write() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Filename), "."+filepath.Base(f.Filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	SortIdPairs(f.Pairs)
+	err = WriteIdPairs(tmp, f.Format, f.Pairs)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), f.perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Filename)
+}
+
+// Close unlocks the file and removes the lock file
+func (f *IdFile) Close() error {
+	if f.lock == nil {
+		return nil
+	}
+	// remove the lock file before unlocking, so processes waiting for the
+	// removed lock file find it and retry with a new one. Opened files can't
+	// be removed on windows, the lock file is kept there.
+	os.Remove(f.lock.Name())
+	err := unlockFile(f.lock)
+	if closeErr := f.lock.Close(); err == nil {
+		err = closeErr
+	}
+	f.lock = nil
+	return err
+}
+
+// lockPath creates and locks the lock file path. The lock file may be removed
+// by its owner while waiting for the lock, then a new one is created and locked.
+func lockPath(path string) (*os.File, error) {
+	for {
+		lock, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(lock); err != nil {
+			lock.Close()
+			return nil, err
+		}
+		locked, err := lock.Stat()
+		if err == nil {
+			current, statErr := os.Stat(path)
+			if statErr == nil && os.SameFile(locked, current) {
+				return lock, nil
+			}
+			if statErr != nil && !os.IsNotExist(statErr) {
+				err = statErr
+			}
+		}
+		unlockFile(lock)
+		lock.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadIdPairs reads key-id pairs formatted by format from reader
+func ReadIdPairs(reader io.Reader, format string) ([]IdPair, error) {
+	switch format {
+	case IdFormatText:
+		return readIdLines(reader, "=")
+	case IdFormatJSON:
+		return readIdObject(json.NewDecoder(reader))
+	case IdFormatYAML:
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		var items yaml.MapSlice
+		if err := yaml.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		pairs := make([]IdPair, 0, len(items))
+		for _, item := range items {
+			id, ok := item.Value.(int)
+			if !ok {
+				return nil, fmt.Errorf("invalid id %v of %v", item.Value, item.Key)
+			}
+			pairs = append(pairs, IdPair{Key: fmt.Sprint(item.Key), Id: id})
+		}
+		return pairs, nil
+	default:
+		return nil, errors.New("unsupported format of id file: " + format)
+	}
+}
+
+// readIdObject reads key-id pairs of a json object in order, duplicated keys are kept
+func readIdObject(decoder *json.Decoder) ([]IdPair, error) {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("invalid id file: want object, got %v", token)
+	}
+	var pairs []IdPair
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		pair := IdPair{Key: token.(string)}
+		if err := decoder.Decode(&pair.Id); err != nil {
+			return nil, fmt.Errorf("invalid id of %s: %v", pair.Key, err)
+		}
+		pairs = append(pairs, pair)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// SortIdPairs sorts pairs by scope and id, ids of beans first
+func SortIdPairs(pairs []IdPair) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		si, sj := IdScope(pairs[i].Key), IdScope(pairs[j].Key)
+		if si != sj {
+			return si < sj
+		}
+		if pairs[i].Id != pairs[j].Id {
+			return pairs[i].Id < pairs[j].Id
+		}
+		return pairs[i].Key < pairs[j].Key
+	})
+}
+
+// WriteIdPairs writes key-id pairs formatted by format to writer
+func WriteIdPairs(w io.Writer, format string, pairs []IdPair) error {
+	var buf bytes.Buffer
+	switch format {
+	case IdFormatText:
+		for _, pair := range pairs {
+			fmt.Fprintf(&buf, "%s=%d\n", pair.Key, pair.Id)
+		}
+	case IdFormatJSON:
+		buf.WriteString("{")
+		for i, pair := range pairs {
+			key, err := json.Marshal(pair.Key)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			fmt.Fprintf(&buf, "\n  %s: %d", key, pair.Id)
+		}
+		buf.WriteString("\n}\n")
+	case IdFormatYAML:
+		m := make(yaml.MapSlice, 0, len(pairs))
+		for _, pair := range pairs {
+			m = append(m, yaml.MapItem{Key: pair.Key, Value: pair.Id})
+		}
+		data, err := yaml.Marshal(m)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	default:
+		return errors.New("unsupported format of id file: " + format)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,196 @@
+package build
+
+import (
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// Parents returns beans extended by the bean directly, unresolved bases are ignored
+func (bean *Bean) Parents() []*Bean {
+	var parents []*Bean
+	for _, typ := range bean.Extends {
+		if t, ok := typ.(*StructType); ok && t.bean != nil {
+			parents = append(parents, t.bean)
+		}
+	}
+	return parents
+}
+
+// AllFields returns fields of the bean including inherited ones. Fields of parents
+// come first in order of extends, and a field redeclared with the same type
+// overrides the inherited one at its position.
+func (bean *Bean) AllFields() []*Field {
+	list, err := bean.collectFields(make(map[*Bean]bool))
+	if err != nil {
+		// conflicts have been reported while building
+		return bean.Fields
+	}
+	fields := make([]*Field, 0, len(list))
+	for _, f := range list {
+		fields = append(fields, f.field)
+	}
+	return fields
+}
+
+// AllFieldNumbers returns wire numbers of AllFields in the same order, e.g. used
+// as field numbers of protobuf messages. A field keeps its Number in the bean
+// which declares it, unless the number is used by a former field, then it's
+// numbered after the maximum number.
+func (bean *Bean) AllFieldNumbers() []int {
+	list, err := bean.collectFields(make(map[*Bean]bool))
+	if err != nil {
+		list = nil
+		for _, field := range bean.Fields {
+			list = append(list, ownedField{field: field, owner: bean})
+		}
+	}
+	numbers := make([]int, len(list))
+	used := make(map[int]bool)
+	max := 0
+	for i, f := range list {
+		for index, field := range f.owner.Fields {
+			if field == f.field {
+				numbers[i] = field.Number(index)
+				break
+			}
+		}
+		if numbers[i] > max {
+			max = numbers[i]
+		}
+	}
+	for i, number := range numbers {
+		if used[number] {
+			max++
+			numbers[i] = max
+		}
+		used[numbers[i]] = true
+	}
+	return numbers
+}
+
+// ownedField is a field with the bean which declares it
+type ownedField struct {
+	field *Field
+	owner *Bean
+}
+
+func (bean *Bean) collectFields(visiting map[*Bean]bool) ([]ownedField, error) {
+	if visiting[bean] {
+		return nil, errorAt(bean, "invalid recursive extends of %s", bean.QualifiedName())
+	}
+	visiting[bean] = true
+	defer delete(visiting, bean)
+
+	var (
+		list  []ownedField
+		index = make(map[string]int)
+	)
+	for _, parent := range bean.Parents() {
+		if parent.Kind != lexer.STRUCT.String() && parent.Kind != lexer.PROTOCOL.String() {
+			return nil, errorAt(bean, "%s extends %s %s", bean.QualifiedName(), parent.Kind, parent.QualifiedName())
+		}
+		inherited, err := parent.collectFields(visiting)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range inherited {
+			duplicated := false
+			for _, name := range f.field.Names {
+				if i, ok := index[name]; ok {
+					if list[i].field == f.field {
+						// the same field inherited through different paths
+						duplicated = true
+						break
+					}
+					return nil, errorAt(bean, "field %s of %s inherited by %s conflicts with field of %s",
+						name, f.owner.QualifiedName(), bean.QualifiedName(), list[i].owner.QualifiedName())
+				}
+			}
+			if duplicated {
+				continue
+			}
+			for _, name := range f.field.Names {
+				index[name] = len(list)
+			}
+			list = append(list, f)
+		}
+	}
+	for _, field := range bean.Fields {
+		f := ownedField{field: field, owner: bean}
+		overridden := -1
+		for _, name := range field.Names {
+			i, ok := index[name]
+			if !ok {
+				continue
+			}
+			if len(field.Names) > 1 || len(list[i].field.Names) > 1 || !sameType(field.Type, list[i].field.Type) {
+				return nil, errorAt(field, "field %s of %s conflicts with field of %s", name, bean.QualifiedName(), list[i].owner.QualifiedName())
+			}
+			overridden = i
+		}
+		if overridden >= 0 {
+			list[overridden] = f
+			continue
+		}
+		for _, name := range field.Names {
+			index[name] = len(list)
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+// sameType reports whether types a and b are identical
+func sameType(a, b Type) bool {
+	switch x := a.(type) {
+	case *BasicType:
+		y, ok := b.(*BasicType)
+		return ok && x.Name == y.Name
+	case *StructType:
+		y, ok := b.(*StructType)
+		return ok && x.Package == y.Package && x.Name == y.Name
+	case *ArrayType:
+		y, ok := b.(*ArrayType)
+		if !ok || !sameType(x.T, y.T) {
+			return false
+		}
+		xs, _ := IntFromExpr(x.Size)
+		ys, _ := IntFromExpr(y.Size)
+		return xs == ys
+	case *VectorType:
+		y, ok := b.(*VectorType)
+		return ok && sameType(x.T, y.T)
+	case *SetType:
+		y, ok := b.(*SetType)
+		return ok && sameType(x.T, y.T)
+	case *MapType:
+		y, ok := b.(*MapType)
+		return ok && x.Ordered == y.Ordered && sameType(x.K, y.K) && sameType(x.V, y.V)
+	}
+	return false
+}
+
+// checkInheritance reports conflicts of fields inherited by struct and protocol beans
+func checkInheritance(builder *Builder) error {
+	var check func(pkg *Package, beans []*Bean) error
+	check = func(pkg *Package, beans []*Bean) error {
+		for _, bean := range beans {
+			if len(bean.Extends) > 0 && bean.Kind != lexer.SERVICE.String() {
+				if _, err := bean.collectFields(make(map[*Bean]bool)); err != nil {
+					return err
+				}
+			}
+			if err := check(pkg, bean.Nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, pkg := range builder.SortedPackages {
+		for _, file := range pkg.Files {
+			if err := check(pkg, file.Beans); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package build
+
+import "os"
+
+// lockFile does nothing on platforms without file locking
+func lockFile(file *os.File) error { return nil }
+
+func unlockFile(file *os.File) error { return nil }
@@ -18,6 +18,10 @@ type PluginRuntimeConfig struct {
 	ExtentionsDir string
 	Extensions    []Extension
 	Envvars       map[string]string
+	// TemplatesRootDir contains builtin template kinds, e.g. $MIDROOT/templates
+	TemplatesRootDir string
+	// Types overrides type mappings of the language, e.g. {"int": "int32"}
+	Types map[string]string
 }
 
 func (config PluginRuntimeConfig) Encode() string {
@@ -66,6 +70,10 @@ func (config *PluginRuntimeConfig) FloatEnv(name string) float64 {
 	return f
 }
 
+// DefaultPluginBin is the generator binary of plugins which don't specify `bin`,
+// it selects backend by language of the plugin
+const DefaultPluginBin = "mid-gen"
+
 type Plugin struct {
 	Lang         string `json:"lang"`
 	Name         string `json:"name"`
@@ -76,6 +84,9 @@ type Plugin struct {
 }
 
 func (plugin *Plugin) Init() error {
+	if plugin.Bin == "" {
+		plugin.Bin = DefaultPluginBin
+	}
 	bin, err := exec.LookPath(plugin.Bin)
 	if err != nil {
 		return err
@@ -0,0 +1,102 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/midlang/mid/src/mid/ast"
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// NodePos holds position of node in mid source file
+type NodePos struct {
+	// Pos is the position of node, it's invalid if unknown
+	Pos lexer.Position
+
+	// offset is the position in FileSet which resolved to Pos by BuildFile
+	offset lexer.Pos
+}
+
+func newNodePos(offset lexer.Pos) NodePos {
+	return NodePos{offset: offset}
+}
+
+// Position returns position of node in mid source file
+func (n NodePos) Position() lexer.Position { return n.Pos }
+
+func (n *NodePos) resolvePos(file *lexer.File) {
+	if n.offset.IsValid() {
+		n.Pos = file.Position(n.offset)
+	}
+}
+
+// positioner is a node which has position in mid source file
+type positioner interface {
+	Position() lexer.Position
+}
+
+// errorAt returns an error prefixed with position of node like errors of parser,
+// e.g. demo.mid:3:2: message. The position is omitted if it's unknown.
+func errorAt(node positioner, format string, args ...interface{}) error {
+	if pos := node.Position(); pos.IsValid() {
+		return fmt.Errorf("%v: %s", pos, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// fieldPos returns beginning of field, name is used for method and enum member
+func fieldPos(field *ast.Field) lexer.Pos {
+	if len(field.Options) > 0 {
+		return field.Options[0].Begin()
+	}
+	if _, isFunc := field.Type.(*ast.FuncType); field.Type != nil && !isFunc {
+		return field.Type.Begin()
+	}
+	if len(field.Names) > 0 {
+		return field.Names[0].Begin()
+	}
+	return lexer.NoPos
+}
+
+func (f *File) resolvePositions(file *lexer.File) {
+	for _, bean := range f.Beans {
+		bean.resolvePositions(file)
+	}
+	for _, decl := range f.Decls {
+		decl.resolvePos(file)
+		for _, c := range decl.Consts {
+			c.resolvePos(file)
+		}
+	}
+	for _, g := range f.Groups {
+		g.resolvePositions(file)
+	}
+}
+
+func (g *Group) resolvePositions(file *lexer.File) {
+	g.resolvePos(file)
+	for _, sub := range g.Groups {
+		sub.resolvePositions(file)
+	}
+}
+
+func (bean *Bean) resolvePositions(file *lexer.File) {
+	bean.resolvePos(file)
+	for _, field := range bean.Fields {
+		field.resolvePositions(file)
+	}
+	for _, nested := range bean.Nested {
+		nested.resolvePositions(file)
+	}
+}
+
+func (field *Field) resolvePositions(file *lexer.File) {
+	field.resolvePos(file)
+	if ft, ok := field.Type.(*FuncType); ok {
+		for _, param := range ft.Params {
+			param.resolvePositions(file)
+		}
+		for _, result := range ft.Results {
+			result.resolvePositions(file)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package build
+
+import (
+	"strings"
+
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// typeResolver resolves kinds of referenced beans and types which reference `type` beans
+type typeResolver struct {
+	builder  *Builder
+	resolved map[*Bean]bool
+	visiting map[*Bean]bool
+}
+
+func resolveTypes(builder *Builder) error {
+	r := &typeResolver{
+		builder:  builder,
+		resolved: make(map[*Bean]bool),
+		visiting: make(map[*Bean]bool),
+	}
+	for _, pkg := range builder.SortedPackages {
+		for _, file := range pkg.Files {
+			for _, bean := range file.Beans {
+				if err := r.resolveBean(pkg, bean); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lookup finds the bean referenced by t which used in bean scope of package pkg.
+// Name of nested bean would be qualified, e.g. Inner used in Outer becomes Outer.Inner
+func (r *typeResolver) lookup(pkg *Package, scope *Bean, t *StructType) (*Package, *Bean) {
+	if t.Package != "" {
+		// Package may be an outer bean rather than a package
+		if _, bean := r.lookupInScope(pkg, scope, t.Package); bean != nil || r.builder.Packages[t.Package] == nil {
+			t.Name = t.Package + "." + t.Name
+			t.Package = ""
+		}
+	}
+	if t.Package != "" {
+		pkg = r.builder.Packages[t.Package]
+		return pkg, pkg.FindBean(t.Name)
+	}
+	name, bean := r.lookupInScope(pkg, scope, t.Name)
+	if bean != nil {
+		t.Name = name
+	}
+	return pkg, bean
+}
+
+// lookupInScope looks up bean by name from the innermost scope outward
+func (r *typeResolver) lookupInScope(pkg *Package, scope *Bean, name string) (string, *Bean) {
+	if scope != nil {
+		for outer := scope.QualifiedName(); outer != ""; {
+			qualified := outer + "." + name
+			if bean := pkg.FindBean(qualified); bean != nil {
+				return qualified, bean
+			}
+			if i := strings.LastIndex(outer, "."); i >= 0 {
+				outer = outer[:i]
+			} else {
+				outer = ""
+			}
+		}
+	}
+	return name, pkg.FindBean(name)
+}
+
+func (r *typeResolver) resolveBean(pkg *Package, bean *Bean) error {
+	if r.resolved[bean] {
+		return nil
+	}
+	if r.visiting[bean] {
+		return errorAt(bean, "invalid recursive type %s.%s", pkg.Name, bean.QualifiedName())
+	}
+	r.visiting[bean] = true
+	defer delete(r.visiting, bean)
+
+	if bean.Type != nil {
+		if err := r.resolveType(pkg, bean, bean.Type); err != nil {
+			return err
+		}
+	}
+	for _, typ := range bean.Extends {
+		if err := r.resolveType(pkg, bean, typ); err != nil {
+			return err
+		}
+	}
+	for _, field := range bean.Fields {
+		if err := r.resolveType(pkg, bean, field.Type); err != nil {
+			return err
+		}
+		if t, ok := field.Type.(*FuncType); ok && t.Throws != nil {
+			st, ok := t.Throws.(*StructType)
+			if !ok || st.Kind != lexer.ENUM.String() {
+				return errorAt(field, "service %s.%s: thrown type must be an enum", pkg.Name, bean.QualifiedName())
+			}
+			st.bean.Thrown = true
+		}
+	}
+	for _, nested := range bean.Nested {
+		if err := r.resolveBean(pkg, nested); err != nil {
+			return err
+		}
+	}
+	r.resolved[bean] = true
+	return nil
+}
+
+func (r *typeResolver) resolveType(pkg *Package, scope *Bean, typ Type) error {
+	switch t := typ.(type) {
+	case *StructType:
+		targetPkg, target := r.lookup(pkg, scope, t)
+		if target == nil {
+			return nil
+		}
+		t.Kind = target.Kind
+		t.Ref = ObjectId(JoinBeanKey(targetPkg.Name, target.QualifiedName()))
+		t.ImportPath = targetPkg.Path
+		t.Id = target.Id
+		t.bean = target
+		if !target.IsTypeDef() {
+			return nil
+		}
+		// only `type` beans are resolved recursively, so a struct could contain itself
+		if err := r.resolveBean(targetPkg, target); err != nil {
+			return err
+		}
+		t.Underlying = target.Type
+	case *ArrayType:
+		return r.resolveType(pkg, scope, t.T)
+	case *VectorType:
+		return r.resolveType(pkg, scope, t.T)
+	case *SetType:
+		return r.resolveType(pkg, scope, t.T)
+	case *MapType:
+		if err := r.resolveType(pkg, scope, t.K); err != nil {
+			return err
+		}
+		return r.resolveType(pkg, scope, t.V)
+	case *FuncType:
+		for _, param := range t.Params {
+			if err := r.resolveType(pkg, scope, param.Type); err != nil {
+				return err
+			}
+		}
+		for _, result := range t.Results {
+			if err := r.resolveType(pkg, scope, result.Type); err != nil {
+				return err
+			}
+		}
+		if t.Throws != nil {
+			if err := r.resolveType(pkg, scope, t.Throws); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveIds copies allocated ids of referenced beans to types which reference them
+func resolveIds(builder *Builder) {
+	for _, pkg := range builder.SortedPackages {
+		for _, file := range pkg.Files {
+			for _, bean := range file.Beans {
+				resolveBeanIds(bean)
+			}
+		}
+	}
+}
+
+func resolveBeanIds(bean *Bean) {
+	if bean.Type != nil {
+		resolveTypeIds(bean.Type)
+	}
+	for _, typ := range bean.Extends {
+		resolveTypeIds(typ)
+	}
+	for _, field := range bean.Fields {
+		resolveTypeIds(field.Type)
+	}
+	for _, nested := range bean.Nested {
+		resolveBeanIds(nested)
+	}
+}
+
+func resolveTypeIds(typ Type) {
+	switch t := typ.(type) {
+	case *StructType:
+		if t.bean != nil {
+			t.Id = t.bean.Id
+		}
+	case *ArrayType:
+		resolveTypeIds(t.T)
+	case *VectorType:
+		resolveTypeIds(t.T)
+	case *SetType:
+		resolveTypeIds(t.T)
+	case *MapType:
+		resolveTypeIds(t.K)
+		resolveTypeIds(t.V)
+	case *FuncType:
+		for _, param := range t.Params {
+			resolveTypeIds(param.Type)
+		}
+		for _, result := range t.Results {
+			resolveTypeIds(result.Type)
+		}
+		if t.Throws != nil {
+			resolveTypeIds(t.Throws)
+		}
+	}
+}
+
+// Underlying returns the underlying type of typ if typ references a `type` bean,
+// otherwise typ returned
+func Underlying(typ Type) Type {
+	for {
+		t, ok := typ.(*StructType)
+		if !ok || t.Underlying == nil {
+			return typ
+		}
+		typ = t.Underlying
+	}
+}
@@ -0,0 +1,149 @@
+package build
+
+import (
+	"github.com/midlang/mid/src/mid/lexer"
+)
+
+// Method represents a method of service, which may be inherited from base services
+type Method struct {
+	NodePos
+	// Id is allocated by BeanIdAllocator with key returned by Key, 0 if not allocated
+	Id      int
+	Doc     string
+	Name    string
+	Type    *FuncType
+	Comment string
+	// Package and Service identify the service which declares the method
+	Package string
+	Service string
+}
+
+// Key returns the key of method for BeanIdAllocator, e.g. pkg.Service.method
+func (m Method) Key() string {
+	return JoinBeanKey(m.Package, m.Service+"."+m.Name)
+}
+
+// Inherited reports whether the method is declared by a base service of bean
+func (m Method) Inherited(bean *Bean) bool {
+	return m.Service != bean.QualifiedName()
+}
+
+// HasMethodIds reports whether ids are allocated for methods of service
+func (bean Bean) HasMethodIds() bool {
+	for _, m := range bean.Methods {
+		if m.Id != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceFlattener flattens methods of services and methods of their base services
+type serviceFlattener struct {
+	builder   *Builder
+	flattened map[*Bean]bool
+	visiting  map[*Bean]bool
+}
+
+func flattenServices(builder *Builder) error {
+	f := &serviceFlattener{
+		builder:   builder,
+		flattened: make(map[*Bean]bool),
+		visiting:  make(map[*Bean]bool),
+	}
+	for _, pkg := range builder.SortedPackages {
+		for _, file := range pkg.Files {
+			for _, bean := range file.Beans {
+				if bean.Kind != lexer.SERVICE.String() {
+					continue
+				}
+				if err := f.flatten(pkg, bean); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// flatten fills bean.Methods: methods of base services in order of extends,
+// then methods declared by the service itself
+func (f *serviceFlattener) flatten(pkg *Package, bean *Bean) error {
+	if f.flattened[bean] {
+		return nil
+	}
+	name := JoinBeanKey(pkg.Name, bean.QualifiedName())
+	if f.visiting[bean] {
+		return errorAt(bean, "invalid recursive service %s", name)
+	}
+	f.visiting[bean] = true
+	defer delete(f.visiting, bean)
+
+	var (
+		methods []*Method
+		index   = make(map[string]*Method)
+	)
+	add := func(m *Method) error {
+		if found, ok := index[m.Name]; ok {
+			if found.Key() == m.Key() {
+				// the same method inherited through different paths
+				return nil
+			}
+			// report at the method if it's declared by the service, or at the service otherwise
+			var at positioner = bean
+			if m.Package == pkg.Name && m.Service == bean.QualifiedName() {
+				at = m
+			}
+			return errorAt(at, "service %s: method %s of %s conflicts with %s", name, m.Name, JoinBeanKey(m.Package, m.Service), JoinBeanKey(found.Package, found.Service))
+		}
+		index[m.Name] = m
+		methods = append(methods, m)
+		return nil
+	}
+	for _, typ := range bean.Extends {
+		t, ok := typ.(*StructType)
+		if !ok {
+			return errorAt(bean, "service %s: invalid base type", name)
+		}
+		basePkg := pkg
+		if t.Package != "" {
+			basePkg = f.builder.Packages[t.Package]
+		}
+		var base *Bean
+		if basePkg != nil {
+			base = basePkg.FindBean(t.Name)
+		}
+		if base == nil || base.Kind != lexer.SERVICE.String() {
+			return errorAt(bean, "service %s: base %s is not a service", name, t.String("."))
+		}
+		if err := f.flatten(basePkg, base); err != nil {
+			return err
+		}
+		for _, m := range base.Methods {
+			if err := add(m); err != nil {
+				return err
+			}
+		}
+	}
+	for _, field := range bean.Fields {
+		ft, ok := field.Type.(*FuncType)
+		if !ok || len(field.Names) == 0 {
+			continue
+		}
+		m := &Method{
+			NodePos: field.NodePos,
+			Doc:     field.Doc,
+			Name:    field.Names[0],
+			Type:    ft,
+			Comment: field.Comment,
+			Package: pkg.Name,
+			Service: bean.QualifiedName(),
+		}
+		if err := add(m); err != nil {
+			return err
+		}
+	}
+	bean.Methods = methods
+	f.flattened[bean] = true
+	return nil
+}
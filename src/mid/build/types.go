@@ -29,11 +29,13 @@ func init() {
 	gob.Register(&ArrayType{})
 	gob.Register(&MapType{})
 	gob.Register(&VectorType{})
+	gob.Register(&SetType{})
 	gob.Register(&GenDecl{})
 	gob.Register(&ImportSpec{})
 	gob.Register(&ConstSpec{})
 	gob.Register(&StructType{})
 	gob.Register(&FuncType{})
+	gob.Register(&UnionType{})
 }
 
 //--------------------------------------------------------------
@@ -229,6 +231,10 @@ func (tag Tag) parse(key string) (pairs []tagpair, value string, index int) {
 
 // Field represents a field of struct or protocol
 type Field struct {
+	NodePos
+	// Id is allocated by BeanIdAllocator with kind `field` or `member`, 0 if not allocated.
+	// It's unique in the bean, e.g. used as wire number of field.
+	Id      int
 	Doc     string
 	Options []string
 	Type    Type
@@ -249,6 +255,38 @@ func (field Field) Name() (string, error) {
 	return "", ErrAmbiguousNames
 }
 
+// IsOptional reports whether the field is declared with `optional`
+func (field Field) IsOptional() bool { return field.hasOption(lexer.OPTIONAL.String()) }
+
+// IsRequired reports whether the field is declared with `required`
+func (field Field) IsRequired() bool { return field.hasOption(lexer.REQUIRED.String()) }
+
+func (field Field) hasOption(option string) bool {
+	for _, opt := range field.Options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Number returns id of field if allocated, or index+1 otherwise, e.g. used as wire number of field
+func (field Field) Number(index int) int {
+	if field.Id != 0 {
+		return field.Id
+	}
+	return index + 1
+}
+
+// hasValue reports whether default value of field (or value of enum member) is specified
+func (field Field) hasValue() bool {
+	switch field.Default.(type) {
+	case *BasicLit, Ident:
+		return true
+	}
+	return false
+}
+
 // Value returns default value of field
 func (field Field) Value() string {
 	switch e := field.Default.(type) {
@@ -277,6 +315,7 @@ func (field *Field) AddTag(key, value string) {
 // BuildField builds Field node to Field struct
 func BuildField(field *ast.Field) *Field {
 	out := &Field{
+		NodePos: newNodePos(fieldPos(field)),
 		Doc:     BuildDoc(field.Doc),
 		Options: BuildIdentList(field.Options),
 		Type:    BuildType(field.Type),
@@ -358,6 +397,9 @@ func BuildExpr(expr ast.Expr) Expr {
 	if lit, ok := expr.(*ast.BasicLit); ok {
 		return BuildBasicLit(lit)
 	}
+	if env, ok := expr.(*ast.EnvExpr); ok && env.Value != nil {
+		return BuildBasicLit(env.Value)
+	}
 	// TODO: alert error
 	return &ExprBase{}
 }
@@ -368,11 +410,19 @@ type Type interface {
 	IsArray() bool
 	IsVector() bool
 	IsMap() bool
+	IsSet() bool
 	IsStruct() bool
 	IsString() bool
 	IsInt() bool
 	IsFloat() bool
 	IsBool() bool
+	IsUnion() bool
+	IsTime() bool
+	IsDuration() bool
+	IsDecimal() bool
+	IsUUID() bool
+	IsFunc() bool
+	IsEnum() bool
 }
 
 type TypeBase struct {
@@ -383,11 +433,20 @@ func (TypeBase) TypeNode()      {}
 func (TypeBase) IsArray() bool  { return false }
 func (TypeBase) IsVector() bool { return false }
 func (TypeBase) IsMap() bool    { return false }
+func (TypeBase) IsSet() bool    { return false }
 func (TypeBase) IsStruct() bool { return false }
 func (TypeBase) IsString() bool { return false }
 func (TypeBase) IsInt() bool    { return false }
 func (TypeBase) IsFloat() bool  { return false }
 func (TypeBase) IsBool() bool   { return false }
+func (TypeBase) IsUnion() bool  { return false }
+
+func (TypeBase) IsTime() bool     { return false }
+func (TypeBase) IsDuration() bool { return false }
+func (TypeBase) IsDecimal() bool  { return false }
+func (TypeBase) IsUUID() bool     { return false }
+func (TypeBase) IsFunc() bool     { return false }
+func (TypeBase) IsEnum() bool     { return false }
 
 func BuildType(typ ast.Type) Type {
 	switch t := typ.(type) {
@@ -401,6 +460,8 @@ func BuildType(typ ast.Type) Type {
 		return BuildArray(t)
 	case *ast.VectorType:
 		return BuildVector(t)
+	case *ast.SetType:
+		return BuildSet(t)
 	case *ast.FuncType:
 		return BuildFunc(t)
 	default:
@@ -416,6 +477,11 @@ type BasicType struct {
 func (t BasicType) IsVector() bool { return t.Name == lexer.Bytes.String() }
 func (t BasicType) IsString() bool { return t.Name == lexer.String.String() }
 func (t BasicType) IsBool() bool   { return t.Name == lexer.Bool.String() }
+
+func (t BasicType) IsTime() bool     { return t.Name == lexer.Time.String() }
+func (t BasicType) IsDuration() bool { return t.Name == lexer.Duration.String() }
+func (t BasicType) IsDecimal() bool  { return t.Name == lexer.Decimal.String() }
+func (t BasicType) IsUUID() bool     { return t.Name == lexer.UUID.String() }
 func (t BasicType) IsInt() bool {
 	bt, ok := lexer.LookupType(t.Name)
 	if !ok {
@@ -450,14 +516,17 @@ type MapType struct {
 	TypeBase
 	K Type
 	V Type
+	// Ordered reports whether the map is an omap which keeps insertion order
+	Ordered bool
 }
 
 func (MapType) IsMap() bool { return true }
 
 func BuildMap(t *ast.MapType) *MapType {
 	return &MapType{
-		K: BuildType(t.K),
-		V: BuildType(t.V),
+		K:       BuildType(t.K),
+		V:       BuildType(t.V),
+		Ordered: t.Ordered,
 	}
 }
 
@@ -474,18 +543,60 @@ func BuildVector(t *ast.VectorType) *VectorType {
 	}
 }
 
+type SetType struct {
+	TypeBase
+	T Type
+}
+
+func (SetType) IsSet() bool { return true }
+
+func BuildSet(t *ast.SetType) *SetType {
+	return &SetType{
+		T: BuildType(t.T),
+	}
+}
+
 type StructType struct {
 	TypeBase
 	Package string
 	Name    string
+	// Kind is the kind of referenced bean, e.g. struct, enum, type, union
+	Kind string
+	// Underlying is the underlying type if the struct references a `type` bean, or nil
+	Underlying Type
+	// Ref identifies the referenced bean, e.g. pkg.Name, it could be looked up by Builder.Lookup
+	Ref ObjectId
+	// ImportPath is the path of package which declares the referenced bean, "." for main package
+	ImportPath string
+	// Id is the allocated id of referenced bean, it's filled by AllocateIds, 0 if not allocated
+	Id int
+
+	// bean is the referenced bean linked by resolver, it's not encoded and
+	// linked again after builder decoded
+	bean *Bean
 }
 
 func (StructType) IsStruct() bool { return true }
 
+// IsEnum reports whether t references an enum bean
+func (t StructType) IsEnum() bool { return t.Kind == lexer.ENUM.String() }
+
+// Bean returns the referenced bean which may be declared in another package,
+// nil returned if the reference not resolved
+func (t *StructType) Bean() *Bean { return t.bean }
+
+// IsUnion reports whether t references a union bean or a `type` bean whose
+// underlying type is union
+func (t StructType) IsUnion() bool {
+	return t.Kind == lexer.Union || (t.Underlying != nil && t.Underlying.IsUnion())
+}
+
 func BuildStruct(t *ast.StructType) *StructType {
+	names := []string{BuildIdent(t.Name)}
+	names = append(names, BuildIdentList(t.Nested)...)
 	return &StructType{
 		Package: BuildIdent(t.Package),
-		Name:    BuildIdent(t.Name),
+		Name:    strings.Join(names, "."),
 	}
 }
 
@@ -496,20 +607,60 @@ func (t StructType) String(sep string) string {
 	return t.Package + sep + t.Name
 }
 
+// FuncType represents method of service. Result is set if there is exactly one result,
+// while Results holds all of results including the single one.
 type FuncType struct {
 	TypeBase
-	Params []*Field
-	Result Type
+	Params  []*Field
+	Result  Type
+	Results []*Field
+	Throws  Type // error enum of method or nil
+
+	ClientStream bool
+	ServerStream bool
 }
 
 func BuildFunc(t *ast.FuncType) *FuncType {
-	return &FuncType{
-		Params: BuildFieldList(t.Params),
-		Result: BuildType(t.Result),
+	ft := &FuncType{
+		Params:       BuildFieldList(t.Params),
+		ClientStream: t.ClientStream,
+		ServerStream: t.ServerStream,
+	}
+	if t.Result != nil {
+		ft.Result = BuildType(t.Result)
 	}
+	if t.Throws != nil {
+		ft.Throws = BuildType(t.Throws)
+	}
+	if t.Results != nil {
+		ft.Results = BuildFieldList(t.Results)
+		if len(ft.Results) == 1 {
+			ft.Result = ft.Results[0].Type
+		}
+	} else if ft.Result != nil {
+		ft.Results = []*Field{{Type: ft.Result}}
+	} else {
+		ft.Results = []*Field{}
+	}
+	return ft
+}
+
+func (FuncType) IsFunc() bool { return true }
+
+// IsStreaming reports whether the method streams parameter or result
+func (t FuncType) IsStreaming() bool { return t.ClientStream || t.ServerStream }
+
+// UnionType represents type of union bean, discriminator of the i-th variant is i+1
+// and 0 means none of variants
+type UnionType struct {
+	TypeBase
+	Variants []*Field
 }
 
+func (UnionType) IsUnion() bool { return true }
+
 type Bean struct {
+	NodePos
 	Id      int
 	Kind    string
 	Doc     string
@@ -519,17 +670,28 @@ type Bean struct {
 	Fields  []*Field
 	Comment string
 	Group   string
+	// Type is the underlying type of `type` bean, the UnionType of `union` bean, or nil
+	Type Type
+	// Outer is qualified name of the bean which declares this nested bean, e.g. A.B
+	Outer string
+	// Nested holds beans declared in this bean
+	Nested []*Bean
+	// Methods holds methods of `service` bean including inherited methods
+	Methods []*Method
+	// Thrown reports whether the enum is thrown by methods of services
+	Thrown bool
 }
 
 func (bean *Bean) IsNil() bool { return bean == nil }
 
 func BuildBean(bean *ast.BeanDecl) *Bean {
 	b := &Bean{
-		Kind:   bean.Kind,
-		Doc:    BuildDoc(bean.Doc),
-		Name:   BuildIdent(bean.Name),
-		Tag:    BuildTag(bean.Tag),
-		Fields: BuildFieldList(bean.Fields),
+		NodePos: newNodePos(bean.Begin()),
+		Kind:    bean.Kind,
+		Doc:     BuildDoc(bean.Doc),
+		Name:    BuildIdent(bean.Name),
+		Tag:     BuildTag(bean.Tag),
+		Fields:  BuildFieldList(bean.Fields),
 	}
 	if len(bean.Extends) > 0 {
 		b.Extends = make([]Type, 0, len(bean.Extends))
@@ -537,9 +699,124 @@ func BuildBean(bean *ast.BeanDecl) *Bean {
 			b.Extends = append(b.Extends, BuildType(e))
 		}
 	}
+	if b.Kind == lexer.Union {
+		b.Type = &UnionType{Variants: b.Fields}
+	}
+	for _, decl := range bean.Decls {
+		b.Nested = append(b.Nested, BuildBean(decl))
+	}
+	b.setOuter(b.Outer)
 	return b
 }
 
+// QualifiedName returns name of the bean qualified by outer beans, e.g. Outer.Inner
+func (bean Bean) QualifiedName() string {
+	if bean.Outer == "" {
+		return bean.Name
+	}
+	return bean.Outer + "." + bean.Name
+}
+
+func (bean *Bean) setOuter(outer string) {
+	bean.Outer = outer
+	for _, nested := range bean.Nested {
+		nested.setOuter(bean.QualifiedName())
+	}
+}
+
+// UsesBuiltin reports whether builtin type name, e.g. time, is used in the bean
+// or its nested beans
+func (bean *Bean) UsesBuiltin(name string) bool {
+	if bean.Type != nil && usesBuiltin(bean.Type, name) {
+		return true
+	}
+	for _, field := range bean.Fields {
+		if usesBuiltin(field.Type, name) {
+			return true
+		}
+	}
+	for _, nested := range bean.Nested {
+		if nested.UsesBuiltin(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func usesBuiltin(typ Type, name string) bool {
+	switch t := typ.(type) {
+	case *BasicType:
+		return t.Name == name
+	case *ArrayType:
+		return usesBuiltin(t.T, name)
+	case *VectorType:
+		return usesBuiltin(t.T, name)
+	case *SetType:
+		return name == lexer.Set.String() || usesBuiltin(t.T, name)
+	case *MapType:
+		if t.Ordered && name == lexer.OMap.String() {
+			return true
+		}
+		return usesBuiltin(t.K, name) || usesBuiltin(t.V, name)
+	case *UnionType:
+		for _, variant := range t.Variants {
+			if usesBuiltin(variant.Type, name) {
+				return true
+			}
+		}
+	case *FuncType:
+		for _, param := range t.Params {
+			if usesBuiltin(param.Type, name) {
+				return true
+			}
+		}
+		for _, result := range t.Results {
+			if usesBuiltin(result.Type, name) {
+				return true
+			}
+		}
+		return t.Throws != nil && usesBuiltin(t.Throws, name)
+	}
+	return false
+}
+
+// FindNested finds nested bean by name which may be qualified, e.g. Inner.Deep
+func (bean Bean) FindNested(name string) *Bean {
+	first, rest := name, ""
+	if i := strings.Index(name, "."); i >= 0 {
+		first, rest = name[:i], name[i+1:]
+	}
+	for _, nested := range bean.Nested {
+		if nested.Name == first {
+			if rest == "" {
+				return nested
+			}
+			return nested.FindNested(rest)
+		}
+	}
+	return nil
+}
+
+// BuildTypeDecl builds TypeDecl node to a bean which kind is `type`
+func BuildTypeDecl(decl *ast.TypeDecl) *Bean {
+	return &Bean{
+		NodePos: newNodePos(decl.Begin()),
+		Kind:    lexer.TypeDef,
+		Doc:     BuildDoc(decl.Doc),
+		Name:    BuildIdent(decl.Name),
+		Tag:     BuildTag(decl.Tag),
+		Fields:  []*Field{},
+		Comment: BuildComment(decl.Comment),
+		Type:    BuildType(decl.Type),
+	}
+}
+
+// IsTypeDef reports whether the bean is declared by `type`
+func (bean Bean) IsTypeDef() bool { return bean.Kind == lexer.TypeDef }
+
+// IsUnion reports whether the bean is declared by `union`
+func (bean Bean) IsUnion() bool { return bean.Kind == lexer.Union }
+
 func (bean Bean) FindFieldByName(name string) *Field {
 	for _, field := range bean.Fields {
 		name2, err := field.Name()
@@ -550,6 +827,28 @@ func (bean Bean) FindFieldByName(name string) *Field {
 	return nil
 }
 
+// OptionalFields returns fields declared with `optional`
+func (bean Bean) OptionalFields() []*Field {
+	var fields []*Field
+	for _, field := range bean.Fields {
+		if field.IsOptional() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// RequiredFields returns fields declared with `required`
+func (bean Bean) RequiredFields() []*Field {
+	var fields []*Field
+	for _, field := range bean.Fields {
+		if field.IsRequired() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 func (bean Bean) Field(i int) *Field {
 	if i >= len(bean.Fields) || i < 0 {
 		return nil
@@ -585,6 +884,7 @@ func BuildImportSpec(spec *ast.ImportSpec) *ImportSpec {
 }
 
 type ConstSpec struct {
+	NodePos
 	Doc     string
 	Name    string
 	Value   Expr
@@ -601,6 +901,7 @@ func (c ConstSpec) ValueString() string {
 
 func BuildConstSpec(spec *ast.ConstSpec) *ConstSpec {
 	return &ConstSpec{
+		NodePos: newNodePos(spec.Begin()),
 		Doc:     BuildDoc(spec.Doc),
 		Name:    BuildIdent(spec.Name),
 		Value:   BuildExpr(spec.Value),
@@ -609,6 +910,7 @@ func BuildConstSpec(spec *ast.ConstSpec) *ConstSpec {
 }
 
 type GenDecl struct {
+	NodePos
 	Doc     string
 	Imports []*ImportSpec
 	Consts  []*ConstSpec
@@ -617,7 +919,8 @@ type GenDecl struct {
 
 func BuildGenDecl(decl *ast.GenDecl) *GenDecl {
 	d := &GenDecl{
-		Doc: BuildDoc(decl.Doc),
+		NodePos: newNodePos(decl.Begin()),
+		Doc:     BuildDoc(decl.Doc),
 	}
 	for _, spec := range decl.Specs {
 		switch s := spec.(type) {
@@ -633,6 +936,7 @@ func BuildGenDecl(decl *ast.GenDecl) *GenDecl {
 }
 
 type Group struct {
+	NodePos
 	Doc    string
 	Name   string
 	Tag    Tag
@@ -646,9 +950,10 @@ func (group *Group) IsNil() bool { return group == nil }
 
 func BuildGroup(group *ast.GroupDecl) *Group {
 	g := &Group{
-		Doc:  BuildDoc(group.Doc),
-		Name: BuildIdent(group.Name),
-		Tag:  BuildTag(group.Tag),
+		NodePos: newNodePos(group.Begin()),
+		Doc:     BuildDoc(group.Doc),
+		Name:    BuildIdent(group.Name),
+		Tag:     BuildTag(group.Tag),
 	}
 	for _, decl := range group.Decls {
 		switch d := decl.(type) {
@@ -656,6 +961,10 @@ func BuildGroup(group *ast.GroupDecl) *Group {
 			b := BuildBean(d)
 			b.Group = g.Name
 			g.Beans = append(g.Beans, b)
+		case *ast.TypeDecl:
+			b := BuildTypeDecl(d)
+			b.Group = g.Name
+			g.Beans = append(g.Beans, b)
 		case *ast.GenDecl:
 			gd := BuildGenDecl(d)
 			gd.Group = g.Name
@@ -706,6 +1015,8 @@ func BuildFile(file *ast.File) *File {
 		switch d := decl.(type) {
 		case *ast.BeanDecl:
 			f.Beans = append(f.Beans, BuildBean(d))
+		case *ast.TypeDecl:
+			f.Beans = append(f.Beans, BuildTypeDecl(d))
 		case *ast.GenDecl:
 			f.Decls = append(f.Decls, BuildGenDecl(d))
 		case *ast.GroupDecl:
@@ -715,11 +1026,41 @@ func BuildFile(file *ast.File) *File {
 			f.Decls = group.allGenDecls(f.Decls)
 		}
 	}
+	if file.Source != nil {
+		f.resolvePositions(file.Source)
+	}
 	return f
 }
 
+// UsesBuiltin reports whether builtin type name is used in beans of the group
+func (g *Group) UsesBuiltin(name string) bool {
+	for _, bean := range g.Beans {
+		if bean.UsesBuiltin(name) {
+			return true
+		}
+	}
+	for _, sub := range g.Groups {
+		if sub.UsesBuiltin(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesBuiltin reports whether builtin type name is used in beans of the file
+func (f *File) UsesBuiltin(name string) bool {
+	for _, bean := range f.Beans {
+		if bean.UsesBuiltin(name) {
+			return true
+		}
+	}
+	return false
+}
+
 type Package struct {
-	Name    string
+	Name string
+	// Path is the import path of package, "." for main package
+	Path    string
 	Imports map[string]string
 	Files   []*File
 }
@@ -744,7 +1085,24 @@ func BuildPackage(pkg *ast.Package) *Package {
 	return p
 }
 
+// UsesBuiltin reports whether builtin type name is used in the package
+func (pkg *Package) UsesBuiltin(name string) bool {
+	for _, file := range pkg.Files {
+		if file.UsesBuiltin(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindBean finds bean by name, name may be qualified for nested bean, e.g. Outer.Inner
 func (pkg *Package) FindBean(name string) *Bean {
+	if i := strings.Index(name, "."); i >= 0 {
+		if outer := pkg.FindBean(name[:i]); outer != nil {
+			return outer.FindNested(name[i+1:])
+		}
+		return nil
+	}
 	for _, file := range pkg.Files {
 		for _, bean := range file.Beans {
 			if bean.Name == name {
@@ -1,8 +1,856 @@
 package build
 
-import "testing"
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/midlang/mid/src/mid/ast"
+	"github.com/midlang/mid/src/mid/lexer"
+	"github.com/midlang/mid/src/mid/parser"
+)
 
 func TestParseTag(t *testing.T) {
 	var tag = Tag(`key:"value"`)
 	t.Logf("tag.key=%s", tag.Get("key"))
 }
+
+func buildSource(t *testing.T, src string) (*Builder, error) {
+	fset := lexer.NewFileSet()
+	file, err := parser.ParseFile(fset, "demo.mid", []byte(src))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	pkg := &ast.Package{
+		Name:    file.Name.Name,
+		Scope:   ast.NewScope(nil),
+		Imports: make(map[string]*ast.Object),
+		Files:   map[string]*ast.File{"demo.mid": file},
+	}
+	return Build(map[string]*ast.Package{".": pkg})
+}
+
+func TestTypeDecl(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+type UserId int64;
+type Owner UserId;
+type Users vector<UserId>;
+
+struct Group {
+	Owner owner;
+	Users users;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	owner := pkg.FindBean("Owner")
+	if owner == nil || !owner.IsTypeDef() {
+		t.Fatalf("type Owner not found")
+	}
+	if typ, ok := Underlying(owner.Type).(*BasicType); !ok || typ.Name != "int64" {
+		t.Errorf("underlying type of Owner: want int64, got %s", owner.Type)
+	}
+	group := pkg.FindBean("Group")
+	if typ, ok := group.Fields[1].Type.(*StructType); !ok || typ.Underlying == nil || !typ.Underlying.IsVector() {
+		t.Errorf("underlying type of Group.users should be vector")
+	}
+
+	if _, err := buildSource(t, "package demo;\ntype A B;\ntype B vector<A>;\n"); err == nil {
+		t.Errorf("recursive type should be an error")
+	}
+}
+
+func TestUnion(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+struct Login {
+	string name;
+}
+
+union Payload {
+	Login login;
+	string text;
+}
+
+type Alias Payload;
+
+protocol Message {
+	Payload payload;
+	Alias alias;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	payload := pkg.FindBean("Payload")
+	if payload == nil || !payload.IsUnion() {
+		t.Fatalf("union Payload not found")
+	}
+	if typ, ok := payload.Type.(*UnionType); !ok || len(typ.Variants) != 2 {
+		t.Errorf("Payload should have 2 variants")
+	}
+	message := pkg.FindBean("Message")
+	for _, field := range message.Fields {
+		if !field.Type.IsUnion() {
+			t.Errorf("type of Message.%s should be union", field.Names[0])
+		}
+	}
+	if typ := payload.Fields[0].Type.(*StructType); typ.Kind != "struct" {
+		t.Errorf("kind of Payload.login: want struct, got %q", typ.Kind)
+	}
+}
+
+func TestFieldOptions(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+struct User {
+	int64 id;
+	required string name;
+	optional string nickname;
+	optional int32 age;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	user := builder.Packages["demo"].FindBean("User")
+	if user.Fields[0].IsOptional() || user.Fields[0].IsRequired() {
+		t.Errorf("User.id should be neither optional nor required")
+	}
+	if !user.Fields[1].IsRequired() {
+		t.Errorf("User.name should be required")
+	}
+	if optionals := user.OptionalFields(); len(optionals) != 2 || optionals[0] != user.Fields[2] {
+		t.Errorf("User should have 2 optional fields: nickname, age")
+	}
+	if requireds := user.RequiredFields(); len(requireds) != 1 || requireds[0] != user.Fields[1] {
+		t.Errorf("User should have 1 required field: name")
+	}
+}
+
+func TestNestedBean(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+protocol Login {
+	enum Platform {
+		Ios = 1,
+	}
+	struct Device {
+		enum Kind {
+			Phone = 1,
+		}
+		Kind kind;
+		Platform platform;
+	}
+	Device device;
+}
+
+struct Session {
+	Login.Device device;
+	Login.Device.Kind kind;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	device := pkg.FindBean("Login.Device")
+	if device == nil || device.QualifiedName() != "Login.Device" {
+		t.Fatalf("nested bean Login.Device not found")
+	}
+	if pkg.FindBean("Device") != nil {
+		t.Errorf("nested bean should not be found as top-level bean")
+	}
+	for _, tc := range []struct {
+		bean  *Bean
+		index int
+		name  string
+		kind  string
+	}{
+		{pkg.FindBean("Login"), 0, "Login.Device", "struct"},
+		{device, 0, "Login.Device.Kind", "enum"},
+		{device, 1, "Login.Platform", "enum"},
+		{pkg.FindBean("Session"), 0, "Login.Device", "struct"},
+		{pkg.FindBean("Session"), 1, "Login.Device.Kind", "enum"},
+	} {
+		typ := tc.bean.Fields[tc.index].Type.(*StructType)
+		if typ.Package != "" || typ.Name != tc.name || typ.Kind != tc.kind {
+			t.Errorf("%s.%s: want %s %s, got %s.%s %s", tc.bean.Name, tc.bean.Fields[tc.index].Names[0], tc.kind, tc.name, typ.Package, typ.Name, typ.Kind)
+		}
+	}
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+struct Order {
+	uuid id;
+	decimal amount;
+	map<string,time> created;
+	duration ttl;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	order := pkg.FindBean("Order")
+	checks := []func(Type) bool{Type.IsUUID, Type.IsDecimal, Type.IsMap, Type.IsDuration}
+	for i, check := range checks {
+		if !check(order.Fields[i].Type) {
+			t.Errorf("unexpected type of Order.%s", order.Fields[i].Names[0])
+		}
+	}
+	for _, name := range []string{"uuid", "decimal", "time", "duration"} {
+		if !pkg.UsesBuiltin(name) {
+			t.Errorf("package should use %s", name)
+		}
+	}
+	if pkg.UsesBuiltin("bytes") {
+		t.Errorf("package should not use bytes")
+	}
+}
+
+func TestSetAndOrderedMap(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+struct Bag {
+	set<string> tags;
+	omap<string,int64> counts;
+	map<string,int64> plain;
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	bag := pkg.FindBean("Bag")
+	if typ, ok := bag.Fields[0].Type.(*SetType); !ok || !typ.IsSet() || !typ.T.IsString() {
+		t.Errorf("Bag.tags should be a set of string")
+	}
+	if typ, ok := bag.Fields[1].Type.(*MapType); !ok || !typ.Ordered {
+		t.Errorf("Bag.counts should be an ordered map")
+	}
+	if typ := bag.Fields[2].Type.(*MapType); typ.Ordered || typ.IsSet() {
+		t.Errorf("Bag.plain should be an unordered map")
+	}
+	if !pkg.UsesBuiltin("omap") || !pkg.UsesBuiltin("set") {
+		t.Errorf("package should use omap and set")
+	}
+}
+
+func TestServiceMethods(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+enum ErrCode {
+	Ok = 0,
+}
+
+struct Message {
+	string text;
+}
+
+service Chat {
+	chat(stream Message) stream Message throws ErrCode
+	login(string user) (string token, int64 expires) throws ErrCode
+	find(int64 uid) Message
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	chat := builder.Packages["demo"].FindBean("Chat")
+	ft := chat.Fields[0].Type.(*FuncType)
+	if !ft.ClientStream || !ft.ServerStream || !ft.IsStreaming() {
+		t.Errorf("chat should be bidirectional streaming")
+	}
+	if throws, ok := ft.Throws.(*StructType); !ok || throws.Name != "ErrCode" || throws.Kind != "enum" {
+		t.Errorf("chat should throw enum ErrCode")
+	}
+	if !builder.Packages["demo"].FindBean("ErrCode").Thrown {
+		t.Errorf("ErrCode should be marked as thrown")
+	}
+	if ft := chat.Fields[1].Type.(*FuncType); len(ft.Results) != 2 || ft.Result != nil || ft.Results[1].Names[0] != "expires" {
+		t.Errorf("login should have 2 named results")
+	}
+	if ft := chat.Fields[2].Type.(*FuncType); len(ft.Results) != 1 || ft.Result == nil || ft.Throws != nil {
+		t.Errorf("find should have a single result")
+	}
+
+	if _, err := buildSource(t, "package demo;\nstruct Message {\n\tstring text;\n}\nservice Chat {\n\tfind() Message throws Message\n}\n"); err == nil {
+		t.Errorf("throwing a non-enum type should be an error")
+	}
+}
+
+func TestServiceInheritance(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+service Base {
+	ping() int64
+}
+
+service Left extends Base {
+	left()
+}
+
+service Right extends Base {
+	right()
+}
+
+service Chat extends Left, Right {
+	hello()
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	chat := builder.Packages["demo"].FindBean("Chat")
+	var names []string
+	for _, m := range chat.Methods {
+		names = append(names, m.Name)
+	}
+	if got := strings.Join(names, ","); got != "ping,left,right,hello" {
+		t.Errorf("want methods ping,left,right,hello, got %s", got)
+	}
+	if key := chat.Methods[0].Key(); key != "demo.Base.ping" || !chat.Methods[0].Inherited(chat) {
+		t.Errorf("ping should be inherited from demo.Base, got %s", key)
+	}
+	if key := chat.Methods[3].Key(); key != "demo.Chat.hello" || chat.Methods[3].Inherited(chat) {
+		t.Errorf("want key demo.Chat.hello, got %s", key)
+	}
+
+	for _, src := range []string{
+		"package demo;\nservice Base {\n\tping()\n}\nservice Chat extends Base {\n\tping()\n}\n",
+		"package demo;\nservice A {\n\tping()\n}\nservice B {\n\tping()\n}\nservice Chat extends A, B {\n}\n",
+		"package demo;\nstruct Base {\n\tint64 id;\n}\nservice Chat extends Base {\n\tping()\n}\n",
+		"package demo;\nservice A extends B {\n\tping()\n}\nservice B extends A {\n\tpong()\n}\n",
+	} {
+		if _, err := buildSource(t, src); err == nil {
+			t.Errorf("invalid service should be an error: %s", src)
+		}
+	}
+}
+
+// buildPackages builds packages by sources keyed by import path
+func buildPackages(t *testing.T, sources map[string]string) (*Builder, error) {
+	fset := lexer.NewFileSet()
+	pkgs := make(map[string]*ast.Package)
+	for path, src := range sources {
+		file, err := parser.ParseFile(fset, path+".mid", []byte(src))
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		pkgs[path] = &ast.Package{
+			Name:    file.Name.Name,
+			Scope:   ast.NewScope(nil),
+			Imports: make(map[string]*ast.Object),
+			Files:   map[string]*ast.File{path + ".mid": file},
+		}
+	}
+	return Build(pkgs)
+}
+
+func TestInheritedFields(t *testing.T) {
+	const base = "package base;\nstruct Entity {\n\tint64 id;\n\tstring name;\n}\n"
+	if _, err := buildPackages(t, map[string]string{
+		"base": base,
+		"demo": "package demo;\nimport \"base\";\nstruct Named {\n\tstring name;\n}\nprotocol User extends base.Entity, Named {\n\tstring email;\n}\n",
+	}); err == nil {
+		t.Fatalf("field name of base.Entity and demo.Named should be a conflict")
+	}
+
+	builder, err := buildPackages(t, map[string]string{
+		"base": base,
+		"demo": "package demo;\nimport \"base\";\nprotocol User extends base.Entity {\n\tstring email;\n\tint64 id;\n}\n",
+	})
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	user := builder.Packages["demo"].FindBean("User")
+	if ref := user.Extends[0].(*StructType).Bean(); ref == nil || ref != builder.Packages["base"].FindBean("Entity") {
+		t.Fatalf("base.Entity should be resolved")
+	}
+	if parents := user.Parents(); len(parents) != 1 || parents[0].Name != "Entity" {
+		t.Errorf("want parent Entity, got %v", parents)
+	}
+	var names []string
+	for _, field := range user.AllFields() {
+		names = append(names, field.Names[0])
+	}
+	if got := strings.Join(names, ","); got != "id,name,email" {
+		t.Errorf("want fields id,name,email, got %s", got)
+	}
+	if user.AllFields()[0] != user.Fields[1] {
+		t.Errorf("User.id should override Entity.id")
+	}
+	// name is the 2nd field of Entity, but number 2 is used by User.id
+	if numbers := user.AllFieldNumbers(); !reflect.DeepEqual(numbers, []int{2, 3, 1}) {
+		t.Errorf("want field numbers [2 3 1], got %v", numbers)
+	}
+
+	decoded := new(Builder)
+	if err := decoded.Decode(builder.Encode()); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(decoded.Packages["demo"].FindBean("User").AllFields()) != 3 {
+		t.Errorf("inherited fields should be linked after decoding")
+	}
+
+	for _, src := range []string{
+		"package demo;\nstruct A {\n\tint64 id;\n}\nstruct B extends A {\n\tstring id;\n}\n",
+		"package demo;\nstruct A extends B {\n\tint64 a;\n}\nstruct B extends A {\n\tint64 b;\n}\n",
+		"package demo;\nenum E {\n\tX = 1,\n}\nstruct B extends E {\n\tint64 b;\n}\n",
+	} {
+		if _, err := buildSource(t, src); err == nil {
+			t.Errorf("invalid inheritance should be an error: %s", src)
+		}
+	}
+}
+
+func TestStructTypeRef(t *testing.T) {
+	builder, err := buildPackages(t, map[string]string{
+		"shared/base": "package base;\nenum Color {\n\tRed = 1,\n}\n",
+		".":           "package demo;\nimport \"shared/base\";\nstruct Paint {\n\tbase.Color color;\n\tPaint next;\n}\n",
+	})
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	color := builder.Lookup("base.Color")
+	if color == nil || builder.Lookup("base.Paint") != nil {
+		t.Fatalf("Lookup should find base.Color only")
+	}
+	color.Id = 7
+	decoded := new(Builder)
+	if err := decoded.Decode(builder.Encode()); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	paint := decoded.Lookup("demo.Paint")
+	typ := paint.Fields[0].Type.(*StructType)
+	if !typ.IsEnum() || typ.Ref != "base.Color" || typ.ImportPath != "shared/base" || typ.Id != 7 {
+		t.Errorf("unexpected reference of base.Color: %+v", typ)
+	}
+	if typ.Bean() != decoded.Lookup(typ.Ref) {
+		t.Errorf("base.Color should be linked after decoding")
+	}
+	if next := paint.Fields[1].Type.(*StructType); next.IsEnum() || next.Ref != "demo.Paint" || next.ImportPath != "." {
+		t.Errorf("unexpected reference of demo.Paint: %+v", next)
+	}
+}
+
+func TestStructTypeIdAfterAllocating(t *testing.T) {
+	const src = "package demo;\nstruct Admin {\n}\nstruct User {\n\tAdmin admin;\n\tvector<Admin> admins;\n}\n"
+	builder, err := buildSource(t, src)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	allocator, _ := NewBeanIdAllocator("hash", "range=100-199")
+	if err := AllocateIds(allocator, builder, map[string]bool{"struct": true}); err != nil {
+		t.Fatalf("allocate error: %v", err)
+	}
+	pkg := builder.Packages["demo"]
+	admin, user := pkg.FindBean("Admin"), pkg.FindBean("User")
+	if typ := user.Fields[0].Type.(*StructType); typ.Id == 0 || typ.Id != admin.Id {
+		t.Errorf("want id %d of field admin, got %d", admin.Id, typ.Id)
+	}
+	if typ := user.Fields[1].Type.(*VectorType).T.(*StructType); typ.Id != admin.Id {
+		t.Errorf("want id %d of element of field admins, got %d", admin.Id, typ.Id)
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	builder, err := buildSource(t, `package demo;
+
+const (
+	Max = 10;
+)
+
+group admin {
+	enum Role {
+		Guest = 1,
+	}
+}
+
+protocol User extends Base {
+	int64 id;
+	optional string name;
+}
+
+service Users {
+	find(int64 id) User
+}
+`)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	decoded := new(Builder)
+	if err := decoded.Decode(builder.Encode()); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	pkg := decoded.Packages["demo"]
+	file := pkg.Files[0]
+	user := pkg.FindBean("User")
+	role := pkg.FindBean("Role")
+	for _, tt := range []struct {
+		what      string
+		node      interface{ Position() lexer.Position }
+		line, col int
+	}{
+		{"const", file.Decls[0].Consts[0], 4, 2},
+		{"group", file.Groups[0], 7, 1},
+		{"enum", role, 8, 2},
+		{"enum member", role.Fields[0], 9, 3},
+		{"protocol", user, 13, 1},
+		{"field", user.Fields[0], 14, 2},
+		{"optional field", user.Fields[1], 15, 2},
+		{"method", pkg.FindBean("Users").Methods[0], 19, 2},
+	} {
+		pos := tt.node.Position()
+		if pos.Filename != "demo.mid" || pos.Line != tt.line || pos.Column != tt.col {
+			t.Errorf("want position of %s demo.mid:%d:%d, got %v", tt.what, tt.line, tt.col, pos)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		{"package demo;\nstruct Message {\n\tstring text;\n}\nservice Chat {\n\tfind() Message throws Message\n}\n", "demo.mid:6:2: service demo.Chat: thrown type must be an enum"},
+		{"package demo;\nstruct A {\n\tint64 id;\n}\nstruct B extends A {\n\tstring id;\n}\n", "demo.mid:6:2: field id of B conflicts with field of A"},
+		{"package demo;\nenum E {\n\tX = 1,\n}\nstruct B extends E {\n}\n", "demo.mid:5:1: B extends enum E"},
+		{"package demo;\nservice A {\n\tping()\n}\nservice B extends A {\n\tping()\n}\n", "demo.mid:6:2: service demo.B: method ping of demo.B conflicts with demo.A"},
+	} {
+		if _, err := buildSource(t, tt.src); err == nil || err.Error() != tt.want {
+			t.Errorf("want error %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestIdAllocators(t *testing.T) {
+	const src = "package demo;\nstruct User {\n}\nstruct Admin `id:\"7\"` {\n}\nservice Chat {\n\tping()\n}\n"
+	kinds := map[string]bool{"struct": true, "service": true}
+	allocate := func(name, opts string) map[string]int {
+		builder, err := buildSource(t, src)
+		if err != nil {
+			t.Fatalf("build error: %v", err)
+		}
+		allocator, err := NewBeanIdAllocator(name, opts)
+		if err != nil {
+			t.Fatalf("new allocator %s error: %v", name, err)
+		}
+		defer allocator.Close()
+		if err := AllocateIds(allocator, builder, kinds); err != nil {
+			t.Fatalf("allocator %s: allocate error: %v", name, err)
+		}
+		var buf strings.Builder
+		if err := allocator.Output(&buf); err != nil {
+			t.Fatalf("allocator %s: output error: %v", name, err)
+		}
+		ids, err := ReadBeanIds(strings.NewReader(buf.String()), "=")
+		if err != nil {
+			t.Fatalf("allocator %s: read ids error: %v", name, err)
+		}
+		return ids
+	}
+
+	dir := t.TempDir()
+	ids := allocate("sequential", dir+"/seq.txt")
+	want := map[string]int{"demo.Admin": 7, "demo.User": 8, "demo.Chat": 9, "demo.Chat.ping": 10}
+	for key, id := range want {
+		if ids[key] != id {
+			t.Errorf("sequential: want %s=%d, got %d", key, id, ids[key])
+		}
+	}
+
+	ids = allocate("hash", "range=1-99")
+	if again := allocate("hash", "range=1-99"); len(again) != len(ids) {
+		t.Errorf("hash: want %d ids, got %d", len(ids), len(again))
+	} else {
+		for key, id := range ids {
+			if again[key] != id {
+				t.Errorf("hash: id of %s is not deterministic: %d and %d", key, id, again[key])
+			}
+		}
+	}
+	seen := make(map[int]string)
+	for key, id := range ids {
+		if key == "demo.Admin" {
+			if id != 7 {
+				t.Errorf("hash: pinned id of demo.Admin should be 7, got %d", id)
+			}
+		} else if id < 1 || id > 99 {
+			t.Errorf("hash: id %d of %s out of range", id, key)
+		}
+		if other, ok := seen[id]; ok {
+			t.Errorf("hash: duplicated id %d of %s and %s", id, key, other)
+		}
+		seen[id] = key
+	}
+
+	allocator, _ := NewBeanIdAllocator("hash", "range=1-2")
+	if err := allocator.Pin("struct", "demo.User", 1); err != nil {
+		t.Fatalf("pin error: %v", err)
+	}
+	if err := allocator.Pin("struct", "demo.Admin", 1); err == nil {
+		t.Errorf("pinning id 1 to demo.Admin should be a conflict")
+	}
+	if err := allocator.Pin("struct", "demo.Admin", 0); err == nil {
+		t.Errorf("pinning non-positive id should be an error")
+	}
+	if err := allocator.Pin("struct", "demo.Admin", 3); err == nil {
+		t.Errorf("pinning id out of range should be an error")
+	}
+	allocator.Allocate("struct", "demo.A")
+	allocator.Allocate("struct", "demo.B")
+	if err := allocator.Output(nil); err == nil {
+		t.Errorf("allocating more ids than range should be an error")
+	}
+
+	// pinned id must not take the id recorded for other beans
+	filename := dir + "/pin.txt"
+	if err := ioutil.WriteFile(filename, []byte("demo.User=7\n"), 0644); err != nil {
+		t.Fatalf("write %s error: %v", filename, err)
+	}
+	allocator, _ = NewBeanIdAllocator("sequential", filename)
+	builder, _ := buildSource(t, src)
+	if err := AllocateIds(allocator, builder, kinds); err == nil || !strings.Contains(err.Error(), "recorded for demo.User") {
+		t.Errorf("pinning id recorded for demo.User should be an error, got %v", err)
+	}
+	allocator.Close()
+	for _, opts := range []string{"range=10", "range=9-1", "size=1", "ids.txt,format=xml"} {
+		if _, err := NewBeanIdAllocator("hash", opts); err == nil {
+			t.Errorf("hash allocator with invalid options %q should be an error", opts)
+		}
+	}
+}
+
+func TestIdRangesAndFiles(t *testing.T) {
+	const src = "package demo;\nstruct User {\n}\nstruct Admin {\n}\nservice Chat {\n\tping()\n}\n"
+	dir := t.TempDir()
+	for _, filename := range []string{"ids.txt", "ids.json", "ids.yaml"} {
+		filename = dir + "/" + filename
+		for round := 0; round < 2; round++ {
+			builder, err := buildSource(t, src)
+			if err != nil {
+				t.Fatalf("build error: %v", err)
+			}
+			allocator, err := NewBeanIdAllocator("sequential", filename+",range=1-999,kind.method=100-199,pkg.demo.service=200-299")
+			if err != nil {
+				t.Fatalf("%s: new allocator error: %v", filename, err)
+			}
+			if err := AllocateIds(allocator, builder, map[string]bool{"struct": true, "service": true}); err != nil {
+				t.Fatalf("%s: allocate error: %v", filename, err)
+			}
+			if err := allocator.Output(nil); err != nil {
+				t.Fatalf("%s: output error: %v", filename, err)
+			}
+			allocator.Close()
+			chat := builder.Packages["demo"].FindBean("Chat")
+			user := builder.Packages["demo"].FindBean("User")
+			admin := builder.Packages["demo"].FindBean("Admin")
+			if user.Id != 1 || admin.Id != 2 || chat.Id != 200 || chat.Methods[0].Id != 100 {
+				t.Errorf("%s: round %d: want ids 1,2,200,100, got %d,%d,%d,%d", filename, round, user.Id, admin.Id, chat.Id, chat.Methods[0].Id)
+			}
+		}
+		file, err := OpenIdFile(filename, "")
+		if err != nil {
+			t.Fatalf("open %s error: %v", filename, err)
+		}
+		if len(file.Pairs) != 4 {
+			t.Errorf("%s: want 4 ids, got %v", filename, file.Pairs)
+		}
+		retired := map[int]bool{}
+		retire := func(file *IdFile) {
+			for i, pair := range file.Pairs {
+				if pair.Key == "demo.User" {
+					file.Pairs[i].Key = RetiredKey(pair.Key, pair.Id)
+					retired[pair.Id] = true
+				}
+			}
+			if err := file.Write(); err != nil {
+				t.Fatalf("write %s error: %v", filename, err)
+			}
+			file.Close()
+		}
+		retire(file)
+
+		// retired ids never allocated again, even if the key retired more than once
+		for round := 0; round < 2; round++ {
+			allocator, err := NewBeanIdAllocator("sequential", filename)
+			if err != nil {
+				t.Fatalf("%s: round %d: new allocator error: %v", filename, round, err)
+			}
+			if id := allocator.Allocate("struct", "demo.User"); retired[id] {
+				t.Errorf("%s: round %d: retired id %d allocated again", filename, round, id)
+			}
+			if err := allocator.Output(nil); err != nil {
+				t.Fatalf("%s: round %d: output error: %v", filename, round, err)
+			}
+			allocator.Close()
+			file, err := OpenIdFile(filename, "")
+			if err != nil {
+				t.Fatalf("open %s error: %v", filename, err)
+			}
+			retire(file)
+		}
+		file, err = OpenIdFile(filename, "")
+		if err != nil {
+			t.Fatalf("open %s error: %v", filename, err)
+		}
+		n := 0
+		for _, pair := range file.Pairs {
+			if IsRetiredKey(pair.Key) && retired[pair.Id] {
+				n++
+			}
+		}
+		file.Close()
+		if len(retired) != 3 || n != 3 {
+			t.Errorf("%s: want 3 retired ids of demo.User, got %d of %v", filename, n, retired)
+		}
+	}
+
+	// duplicated keys are kept by reading and rejected by allocators
+	for filename, content := range map[string]string{
+		"dup.txt":  "demo.User=1\ndemo.User=2\n",
+		"dup.json": `{"demo.User": 1, "demo.User": 2}`,
+		"dup.yaml": "demo.User: 1\ndemo.User: 2\n",
+	} {
+		filename = dir + "/" + filename
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s error: %v", filename, err)
+		}
+		file, err := OpenIdFile(filename, "")
+		if err != nil {
+			t.Fatalf("open %s error: %v", filename, err)
+		}
+		if len(file.Pairs) != 2 {
+			t.Errorf("%s: want 2 pairs, got %v", filename, file.Pairs)
+		}
+		file.Close()
+		if allocator, err := NewBeanIdAllocator("sequential", filename); err == nil {
+			allocator.Close()
+			t.Errorf("%s: duplicated keys should be an error", filename)
+		}
+	}
+}
+
+func TestIdFileLock(t *testing.T) {
+	filename := t.TempDir() + "/ids.txt"
+	const n = 16
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			file, err := OpenIdFile(filename, "")
+			if err != nil {
+				t.Errorf("open error: %v", err)
+				return
+			}
+			defer file.Close()
+			file.Pairs = append(file.Pairs, IdPair{Key: fmt.Sprintf("demo.User%d", i), Id: len(file.Pairs) + 1})
+			if err := file.Write(); err != nil {
+				t.Errorf("write error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, err := os.Stat(filename + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed after closed, got %v", err)
+	}
+	file, err := OpenIdFile(filename, "")
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	defer file.Close()
+	ids := make(map[int]bool)
+	for _, pair := range file.Pairs {
+		ids[pair.Id] = true
+	}
+	if len(file.Pairs) != n || len(ids) != n {
+		t.Errorf("want %d pairs with different ids, got %v", n, file.Pairs)
+	}
+}
+
+func TestFieldIds(t *testing.T) {
+	const src = "package demo;\nenum Color {\n\tRed = 1,\n\tGreen = 2,\n\tBlue,\n}\nstruct User {\n\tint64 id;\n\tstring name `id:\"5\"`;\n\tstring email;\n}\nstruct Admin {\n\tint64 id;\n}\nservice Chat {\n\tping()\n}\n"
+	filename := t.TempDir() + "/ids.txt"
+	for round := 0; round < 2; round++ {
+		builder, err := buildSource(t, src)
+		if err != nil {
+			t.Fatalf("build error: %v", err)
+		}
+		allocator, err := NewBeanIdAllocator("sequential", filename)
+		if err != nil {
+			t.Fatalf("new allocator error: %v", err)
+		}
+		if err := AllocateIds(allocator, builder, map[string]bool{"struct": true, FieldKind: true, MemberKind: true}); err != nil {
+			t.Fatalf("allocate error: %v", err)
+		}
+		if err := allocator.Output(nil); err != nil {
+			t.Fatalf("output error: %v", err)
+		}
+		allocator.Close()
+
+		pkg := builder.Packages["demo"]
+		user, admin, color := pkg.FindBean("User"), pkg.FindBean("Admin"), pkg.FindBean("Color")
+		var got []int
+		for _, bean := range []*Bean{user, admin, color} {
+			for _, field := range bean.Fields {
+				got = append(got, field.Id)
+			}
+		}
+		if want := []int{6, 5, 7, 1, 1, 2, 3}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("round %d: want field ids %v, got %v", round, want, got)
+		}
+		if user.Id != 1 || admin.Id != 2 {
+			t.Errorf("round %d: want bean ids 1,2, got %d,%d", round, user.Id, admin.Id)
+		}
+		if n := user.Fields[2].Number(2); n != 7 {
+			t.Errorf("round %d: want number 7 of email, got %d", round, n)
+		}
+		if value := color.Fields[2].Value(); value != "3" {
+			t.Errorf("round %d: want value 3 of omitted enum member, got %s", round, value)
+		}
+		if err := CheckEnumValues(builder); err != nil {
+			t.Errorf("round %d: check enum values error: %v", round, err)
+		}
+		if fields := pkg.FindBean("Chat").Fields; fields[0].Id != 0 {
+			t.Errorf("round %d: methods should not be allocated ids as fields", round)
+		}
+	}
+
+	builder, _ := buildSource(t, src)
+	if err := CheckEnumValues(builder); err == nil {
+		t.Errorf("omitted value of enum member without allocated id should be an error")
+	}
+
+	// omitted values never collide with values specified explicitly
+	builder, _ = buildSource(t, "package demo;\nenum Level {\n\tLow,\n\tHigh = 1,\n\tTop = 1,\n\tMax,\n}\n")
+	allocator, _ := NewBeanIdAllocator("sequential", t.TempDir()+"/level.txt")
+	if err := AllocateIds(allocator, builder, map[string]bool{MemberKind: true}); err != nil {
+		t.Fatalf("allocate error: %v", err)
+	}
+	var values []string
+	for _, field := range builder.Packages["demo"].FindBean("Level").Fields {
+		values = append(values, field.Value())
+	}
+	if want := []string{"2", "1", "1", "4"}; fmt.Sprint(values) != fmt.Sprint(want) {
+		t.Errorf("want values %v, got %v", want, values)
+	}
+	allocator.Close()
+
+	builder, _ = buildSource(t, "package demo;\nstruct User {\n\tint64 id `id:\"1\"`;\n\tstring name `id:\"1\"`;\n}\n")
+	allocator, _ = NewBeanIdAllocator("hash", "")
+	if err := AllocateIds(allocator, builder, map[string]bool{FieldKind: true}); err == nil {
+		t.Errorf("fields pinned with the same id should be a conflict")
+	}
+}
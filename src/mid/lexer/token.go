@@ -52,7 +52,13 @@ const (
 	keyword_end
 )
 
-const Group = "group"
+const (
+	Group   = "group"
+	TypeDef = "type"
+	Union   = "union"
+	Stream  = "stream"
+	Throws  = "throws"
+)
 
 var tokens = [...]string{
 	ILLEGAL: "ILLEGAL",
@@ -163,6 +169,14 @@ const (
 	Map                        // map<K,V>
 	Vector                     // vector<T>
 	Array                      // array<T,Size>
+	Set                        // set<T>
+	OMap                       // omap<K,V>, map keeps insertion order
+
+	// well-known types with agreed wire representations
+	Time     // time, seconds and nanoseconds since Unix epoch
+	Duration // duration, nanoseconds
+	Decimal  // decimal, exact decimal number in text
+	UUID     // uuid, 16 bytes
 )
 
 var builtinTypes = [...]string{
@@ -186,6 +200,13 @@ var builtinTypes = [...]string{
 	Map:     "map",
 	Vector:  "vector",
 	Array:   "array",
+	Set:     "set",
+	OMap:    "omap",
+
+	Time:     "time",
+	Duration: "duration",
+	Decimal:  "decimal",
+	UUID:     "uuid",
 }
 
 var revBuiltinTypes = make(map[string]BuiltinType)
@@ -226,6 +247,8 @@ func (bt BuiltinType) IsInt() bool {
 	return false
 }
 
-func (bt BuiltinType) IsFloat() bool     { return bt == Float32 || bt == Float64 }
-func (bt BuiltinType) IsNumber() bool    { return bt.IsInt() || bt.IsFloat() }
-func (bt BuiltinType) IsContainer() bool { return bt == Array || bt == Map || bt == Vector }
+func (bt BuiltinType) IsFloat() bool  { return bt == Float32 || bt == Float64 }
+func (bt BuiltinType) IsNumber() bool { return bt.IsInt() || bt.IsFloat() }
+func (bt BuiltinType) IsContainer() bool {
+	return bt == Array || bt == Map || bt == Vector || bt == Set || bt == OMap
+}
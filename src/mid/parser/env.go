@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Env holds custom environment variables (e.g. midc -E options) which take
+// precedence over variables of the OS environment
+type Env map[string]string
+
+// Lookup looks up variable by name in env, then in the OS environment
+func (env Env) Lookup(name string) (string, bool) {
+	if env != nil {
+		if value, ok := env[name]; ok {
+			return value, true
+		}
+	}
+	return os.LookupEnv(name)
+}
+
+// Resolve resolves variable name, the default value would be used if variable
+// not found and hasDefault is true
+func (env Env) Resolve(name, dft string, hasDefault bool) (string, error) {
+	if value, ok := env.Lookup(name); ok {
+		return value, nil
+	}
+	if hasDefault {
+		return dft, nil
+	}
+	return "", fmt.Errorf("undefined environment variable %s", name)
+}
+
+// Expand replaces $NAME and ${NAME:-default} in s by values of variables.
+// `$$NAME` represents a literal `$NAME`, other `$$` and a `$` not followed by
+// a name are kept as they are.
+func (env Env) Expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == '$' {
+			if _, n := envRefAt(s[i+1:]); n > 0 {
+				buf.WriteByte('$')
+			} else {
+				buf.WriteString("$$")
+			}
+			i++
+			continue
+		}
+		ref, n := envRefAt(s[i:])
+		if n == 0 {
+			buf.WriteByte(s[i])
+			continue
+		}
+		name, dft, hasDefault, err := parseEnvRef(ref)
+		if err != nil {
+			return "", err
+		}
+		value, err := env.Resolve(name, dft, hasDefault)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(value)
+		i += n - 1
+	}
+	return buf.String(), nil
+}
+
+// ExpandQuoted expands content of quoted string literal lit and quotes the
+// result again, raw string literals are kept raw if possible. lit is returned
+// as it is if it's not a valid string literal.
+func (env Env) ExpandQuoted(lit string) (string, error) {
+	s, err := strconv.Unquote(lit)
+	if err != nil || !strings.Contains(s, "$") {
+		return lit, nil
+	}
+	if s, err = env.Expand(s); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(lit, "`") && strconv.CanBackquote(s) {
+		return "`" + s + "`", nil
+	}
+	return strconv.Quote(s), nil
+}
+
+// envRefAt returns the environment variable reference at the beginning of s
+// and its length, s must begin with `$`
+func envRefAt(s string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0
+		}
+		return s[:end+1], end + 1
+	}
+	n := 1
+	for _, r := range s[1:] {
+		if !isEnvNameRune(r, n-1) {
+			break
+		}
+		n += len(string(r))
+	}
+	if n == 1 {
+		return "", 0
+	}
+	return s[:n], n
+}
+
+// parseEnvRef parses reference $NAME or ${NAME:-default}
+func parseEnvRef(ref string) (name, dft string, hasDefault bool, err error) {
+	name = strings.TrimPrefix(ref, "$")
+	if strings.HasPrefix(name, "{") {
+		if !strings.HasSuffix(name, "}") {
+			err = fmt.Errorf("missing '}' in environment variable %s", ref)
+			return
+		}
+		name = name[1 : len(name)-1]
+		if i := strings.Index(name, ":-"); i >= 0 {
+			name, dft, hasDefault = name[:i], name[i+2:], true
+		}
+	}
+	if name == "" {
+		err = fmt.Errorf("missing name of environment variable %s", ref)
+		return
+	}
+	for i, r := range name {
+		if !isEnvNameRune(r, i) {
+			err = fmt.Errorf("invalid name of environment variable %s", ref)
+			return
+		}
+	}
+	return
+}
+
+func isEnvNameRune(r rune, i int) bool {
+	return r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))
+}
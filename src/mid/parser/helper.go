@@ -3,6 +3,7 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"github.com/midlang/mid/src/mid/ast"
 	xscanner "github.com/midlang/mid/src/mid/external/go/scanner"
@@ -32,8 +33,9 @@ func compareError(erri, errj error) bool {
 	}
 }
 
-func (p *parser) init(fset *lexer.FileSet, filename string, src []byte) {
+func (p *parser) init(fset *lexer.FileSet, env Env, filename string, src []byte) {
 	p.file = fset.AddFile(filename, -1, len(src))
+	p.env = env
 	eh := func(s *xscanner.Scanner, msg string) { p.errors.Add(&Error{s.Pos(), msg}) }
 	p.scanner = scanner.NewScanner(p.file, bytes.NewReader(src))
 	p.scanner.Error = eh
@@ -98,6 +100,10 @@ func (p *parser) tryResolve(x ast.Expr, collectUnresolved bool) {
 		if n.T != nil {
 			p.tryResolve(n.T, collectUnresolved)
 		}
+	case *ast.SetType:
+		if n.T != nil {
+			p.tryResolve(n.T, collectUnresolved)
+		}
 	}
 }
 
@@ -128,7 +134,7 @@ func (p *parser) resolve(x ast.Expr) {
 
 func (p *parser) next0() {
 	p.pos, p.tok, p.lit = p.scanner.Scan()
-	p.pos += lexer.Pos(p.file.Base()) - 1
+	p.pos += lexer.Pos(p.file.Base())
 }
 
 func (p *parser) consumeComment() (comment *ast.Comment, endline int) {
@@ -300,6 +306,44 @@ func (p *parser) parseIdent() *ast.Ident {
 	return &ast.Ident{Pos: pos, Name: name}
 }
 
+func (p *parser) parseTag() *ast.BasicLit {
+	tag := &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
+	if value, err := p.env.ExpandQuoted(tag.Value); err != nil {
+		p.error(tag.TokPos, err.Error())
+	} else {
+		tag.Value = value
+	}
+	p.expect(lexer.STRING)
+	return tag
+}
+
+func (p *parser) parseEnvExpr() *ast.EnvExpr {
+	var (
+		pos = p.pos
+		lit = p.lit
+		x   = &ast.EnvExpr{Dollar: pos}
+	)
+	p.expect(lexer.DOLLAR)
+	name, dft, hasDefault, err := parseEnvRef(lit)
+	if err != nil {
+		p.error(pos, err.Error())
+		return x
+	}
+	x.Name, x.Default, x.HasDefault = name, dft, hasDefault
+	value, err := p.env.Resolve(name, dft, hasDefault)
+	if err != nil {
+		p.error(pos, err.Error())
+		return x
+	}
+	x.Value = &ast.BasicLit{TokPos: pos, Tok: lexer.STRING, Value: strconv.Quote(value)}
+	if _, err := strconv.ParseInt(value, 0, 64); err == nil {
+		x.Value.Tok, x.Value.Value = lexer.INT, value
+	} else if _, err := strconv.ParseFloat(value, 64); err == nil {
+		x.Value.Tok, x.Value.Value = lexer.FLOAT, value
+	}
+	return x
+}
+
 func (p *parser) parseIdentList() (list []*ast.Ident) {
 	list = append(list, p.parseIdent())
 	for p.tok == lexer.COMMA {
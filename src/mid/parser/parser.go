@@ -21,6 +21,7 @@ type parser struct {
 	file    *lexer.File
 	errors  *errors.ErrorList
 	mode    uint
+	env     Env
 
 	pos lexer.Pos
 	tok lexer.Token
@@ -94,6 +95,7 @@ func (p *parser) parseFile() *ast.File {
 		Imports:    p.imports,
 		Unresolved: p.unresolved[0:i],
 		Comments:   p.comments,
+		Source:     p.file,
 	}
 }
 
@@ -156,6 +158,12 @@ func (p *parser) parseDecl(parentScope *ast.Scope, sync func(*parser)) ast.Decl
 		if p.tok == lexer.IDENT && p.lit == lexer.Group {
 			return p.parseGroupDecl(parentScope)
 		}
+		if p.tok == lexer.IDENT && p.lit == lexer.TypeDef {
+			return p.parseTypeDecl(parentScope)
+		}
+		if p.tok == lexer.IDENT && p.lit == lexer.Union {
+			return p.parseBeanDecl(parentScope)
+		}
 		pos := p.pos
 		p.errorExpected(pos, "declaration")
 		sync(p)
@@ -173,8 +181,7 @@ func (p *parser) parseGroupDecl(parentScope *ast.Scope) ast.Decl {
 		decls []ast.Decl
 	)
 	if p.tok == lexer.STRING {
-		tag = &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
-		p.next()
+		tag = p.parseTag()
 	}
 	p.expect(lexer.LBRACE)
 	for p.tok != lexer.RBRACE {
@@ -190,16 +197,52 @@ func (p *parser) parseGroupDecl(parentScope *ast.Scope) ast.Decl {
 	}
 }
 
+func (p *parser) parseTypeDecl(parentScope *ast.Scope) ast.Decl {
+	var (
+		tag   *ast.BasicLit
+		doc   = p.leadComment
+		pos   = p.expect(lexer.IDENT)
+		ident = p.parseIdent()
+		typ   = p.parseTypeName()
+	)
+	if p.tok == lexer.STRING {
+		tag = p.parseTag()
+	}
+	p.expectSemi()
+	decl := &ast.TypeDecl{
+		Pos:     pos,
+		Doc:     doc,
+		Name:    ident,
+		Type:    typ,
+		Tag:     tag,
+		Comment: p.lineComment,
+	}
+	p.declare(decl, nil, parentScope, ast.Bean, ident)
+	p.resolve(typ)
+	return decl
+}
+
 func (p *parser) parseBeanDecl(parentScope *ast.Scope) ast.Decl {
 	var (
 		tag     *ast.BasicLit
 		doc     = p.leadComment
 		tok     = p.tok
-		pos     = p.expectOneOf(lexer.PROTOCOL, lexer.STRUCT, lexer.SERVICE, lexer.ENUM)
-		ident   = p.parseIdent()
+		kind    = tok.String()
+		pos     lexer.Pos
+		ident   *ast.Ident
 		extends []ast.Type
 	)
+	if tok == lexer.IDENT && p.lit == lexer.Union {
+		kind = lexer.Union
+		pos = p.expect(lexer.IDENT)
+	} else {
+		pos = p.expectOneOf(lexer.PROTOCOL, lexer.STRUCT, lexer.SERVICE, lexer.ENUM)
+	}
+	ident = p.parseIdent()
 	if p.tok == lexer.EXTENDS {
+		if kind == lexer.Union {
+			p.error(p.pos, "union "+ident.Name+" cannot extend other beans")
+		}
 		p.next()
 		extends = append(extends, p.parseTypeName())
 		for p.tok == lexer.COMMA {
@@ -208,12 +251,14 @@ func (p *parser) parseBeanDecl(parentScope *ast.Scope) ast.Decl {
 		}
 	}
 	if p.tok == lexer.STRING {
-		tag = &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
-		p.next()
+		tag = p.parseTag()
 	}
 	scope := ast.NewScope(parentScope)
 	lbrace := p.expect(lexer.LBRACE)
-	var list []*ast.Field
+	var (
+		list  []*ast.Field
+		decls []*ast.BeanDecl
+	)
 	switch tok {
 	case lexer.SERVICE:
 		for p.tok == lexer.IDENT {
@@ -223,14 +268,28 @@ func (p *parser) parseBeanDecl(parentScope *ast.Scope) ast.Decl {
 		for p.tok == lexer.IDENT {
 			list = append(list, p.parseEnumSpec(scope))
 		}
-	default:
+	case lexer.IDENT:
 		for p.tok == lexer.IDENT || p.tok == lexer.REQUIRED || p.tok == lexer.OPTIONAL || p.tok == lexer.LPAREN {
-			list = append(list, p.parseFieldDecl(scope))
+			list = append(list, p.parseUnionVariant(scope))
 		}
+	default:
+		// nested declarations are visible in the bean body
+		outer := p.topScope
+		p.topScope = scope
+		for {
+			if p.tok == lexer.STRUCT || p.tok == lexer.ENUM {
+				decls = append(decls, p.parseBeanDecl(scope).(*ast.BeanDecl))
+			} else if p.tok == lexer.IDENT || p.tok == lexer.REQUIRED || p.tok == lexer.OPTIONAL || p.tok == lexer.LPAREN {
+				list = append(list, p.parseFieldDecl(scope))
+			} else {
+				break
+			}
+		}
+		p.topScope = outer
 	}
 	rbrace := p.expect(lexer.RBRACE)
 	spec := &ast.BeanDecl{
-		Kind:    tok.String(),
+		Kind:    kind,
 		Pos:     pos,
 		Doc:     doc,
 		Name:    ident,
@@ -241,11 +300,25 @@ func (p *parser) parseBeanDecl(parentScope *ast.Scope) ast.Decl {
 			List:    list,
 			Closing: rbrace,
 		},
+		Decls: decls,
 	}
 	p.declare(spec, nil, parentScope, ast.Bean, ident)
 	return spec
 }
 
+// parseUnionVariant parses a variant of union which is a field with exactly one name
+func (p *parser) parseUnionVariant(scope *ast.Scope) *ast.Field {
+	pos := p.pos
+	field := p.parseFieldDecl(scope)
+	if len(field.Options) > 0 {
+		p.error(pos, "union variant cannot be optional or required")
+	}
+	if len(field.Names) != 1 {
+		p.error(pos, "union variant must have exactly one name")
+	}
+	return field
+}
+
 func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 	var (
 		doc     = p.leadComment
@@ -257,13 +330,11 @@ func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 	typ = p.parseTypeName()
 	idents = p.parseIdentList()
 	if p.tok == lexer.STRING {
-		tag = &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
-		p.next()
+		tag = p.parseTag()
 	}
 	p.expectSemi()
 	if tag == nil && p.tok == lexer.STRING {
-		tag = &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
-		p.next()
+		tag = p.parseTag()
 	}
 	field := &ast.Field{
 		Doc:     doc,
@@ -288,12 +359,7 @@ func (p *parser) parseMethodSpec(scope *ast.Scope) *ast.Field {
 	if ident := x.Ident(); ident != nil && p.tok == lexer.LPAREN {
 		idents = append(idents, ident)
 		scope := ast.NewScope(nil)
-		params, result := p.parseSignature(scope)
-		typ = &ast.FuncType{
-			Func:   lexer.NoPos,
-			Params: params,
-			Result: result,
-		}
+		typ = p.parseSignature(scope)
 	} else {
 		typ = x
 		p.resolve(typ)
@@ -308,20 +374,24 @@ func (p *parser) parseMethodSpec(scope *ast.Scope) *ast.Field {
 	return spec
 }
 
+// parseEnumSpec parses a member of enum, value of member could be omitted
+// which would be the id allocated with kind `member`
 func (p *parser) parseEnumSpec(scope *ast.Scope) *ast.Field {
 	doc := p.leadComment
 	name := p.parseIdent()
-	p.expect(lexer.ASSIGN)
 	var value ast.Expr
-	if p.tok == lexer.INT {
-		value = &ast.BasicLit{
-			TokPos: p.pos,
-			Tok:    p.tok,
-			Value:  p.lit,
+	if p.tok != lexer.COMMA {
+		p.expect(lexer.ASSIGN)
+		if p.tok == lexer.INT {
+			value = &ast.BasicLit{
+				TokPos: p.pos,
+				Tok:    p.tok,
+				Value:  p.lit,
+			}
+			p.next()
+		} else {
+			value = p.parseIdent()
 		}
-		p.next()
-	} else {
-		value = p.parseIdent()
 	}
 	p.expect(lexer.COMMA)
 	spec := &ast.Field{
@@ -333,34 +403,77 @@ func (p *parser) parseEnumSpec(scope *ast.Scope) *ast.Field {
 	return spec
 }
 
-func (p *parser) parseSignature(scope *ast.Scope) (*ast.FieldList, ast.Type) {
+func (p *parser) parseSignature(scope *ast.Scope) *ast.FuncType {
 	pos := p.pos
-	params := p.parseParameters(scope)
-	curPos := p.pos
-	if p.file.Position(pos).Line != p.file.Position(curPos).Line {
-		return params, nil
+	ft := &ast.FuncType{Func: lexer.NoPos}
+	ft.Params, ft.ClientStream = p.parseParameters(scope, true)
+	// result and `throws` must be placed at the same line with parameters
+	sameLine := func() bool {
+		return p.file.Position(pos).Line == p.file.Position(p.pos).Line
+	}
+	if !sameLine() {
+		return ft
+	}
+	switch {
+	case p.tok == lexer.LPAREN:
+		ft.Results, _ = p.parseParameters(scope, false)
+		for _, result := range ft.Results.List {
+			if (len(result.Names) == 0) != (len(ft.Results.List[0].Names) == 0) {
+				p.error(result.Type.Begin(), "mixed named and unnamed results")
+				break
+			}
+		}
+		if len(ft.Results.List) == 1 && len(ft.Results.List[0].Names) == 0 {
+			ft.Result = ft.Results.List[0].Type
+			ft.Results = nil
+		}
+	case p.tok == lexer.IDENT && p.lit == lexer.Throws:
+	case p.tok == lexer.IDENT && p.lit == lexer.Stream:
+		ft.ServerStream = true
+		p.next()
+		ft.Result = p.parseTypeName()
+	default:
+		ft.Result = p.parseTypeName()
+	}
+	if sameLine() && p.tok == lexer.IDENT && p.lit == lexer.Throws {
+		p.next()
+		ft.Throws = p.parseTypeName()
 	}
-	result := p.parseTypeName()
-	return params, result
+	return ft
 }
 
-func (p *parser) parseParameters(scope *ast.Scope) *ast.FieldList {
-	var params []*ast.Field
+// parseParameters parses parameters in parentheses, the second result reports
+// whether a parameter marked by `stream` if allowStream is true
+func (p *parser) parseParameters(scope *ast.Scope, allowStream bool) (*ast.FieldList, bool) {
+	var (
+		params []*ast.Field
+		stream bool
+	)
 	lparen := p.expect(lexer.LPAREN)
 	if p.tok != lexer.RPAREN {
-		params = p.parseParameterList(scope)
+		params, stream = p.parseParameterList(scope, allowStream)
 	}
 	rparen := p.expect(lexer.RPAREN)
 	return &ast.FieldList{
 		Opening: lparen,
 		List:    params,
 		Closing: rparen,
-	}
+	}, stream
 }
 
-func (p *parser) parseParameterList(scope *ast.Scope) []*ast.Field {
-	var list []*ast.Field
+func (p *parser) parseParameterList(scope *ast.Scope, allowStream bool) ([]*ast.Field, bool) {
+	var (
+		list   []*ast.Field
+		stream bool
+	)
 	for p.tok != lexer.RPAREN {
+		if p.tok == lexer.IDENT && p.lit == lexer.Stream {
+			if !allowStream {
+				p.error(p.pos, "unexpected `stream` in results")
+			}
+			stream = true
+			p.next()
+		}
 		typ := p.parseTypeName()
 		var idents []*ast.Ident
 		tok := p.tok
@@ -375,14 +488,17 @@ func (p *parser) parseParameterList(scope *ast.Scope) []*ast.Field {
 		case lexer.RPAREN:
 		default:
 			p.error(p.pos, "unexpected token "+p.tok.String()+" after type")
-			return list
+			return list, stream
 		}
 		list = append(list, &ast.Field{
 			Type:  typ,
 			Names: idents,
 		})
 	}
-	return list
+	if stream && len(list) > 1 {
+		p.error(list[1].Type.Begin(), "`stream` only allowed for the only parameter")
+	}
+	return list, stream
 }
 
 func (p *parser) parseFieldOptions() []*ast.Ident {
@@ -404,15 +520,21 @@ func (p *parser) parseTypeName() ast.Type {
 		p.next()
 		p.resolve(ident)
 		name := p.parseIdent()
+		var nested []*ast.Ident
+		for p.tok == lexer.PERIOD {
+			p.next()
+			nested = append(nested, p.parseIdent())
+		}
 		return &ast.StructType{
 			Package: ident,
 			Name:    name,
+			Nested:  nested,
 		}
 	}
 	bt, ok := lexer.LookupType(ident.Name)
 	if ok {
 		switch bt {
-		case lexer.Map:
+		case lexer.Map, lexer.OMap:
 			lessPos := p.pos
 			p.expect(lexer.LESS)
 			k := p.parseTypeName()
@@ -426,6 +548,7 @@ func (p *parser) parseTypeName() ast.Type {
 				K:       k,
 				V:       v,
 				Greater: greaterPos,
+				Ordered: bt == lexer.OMap,
 			}
 		case lexer.Array:
 			lessPos := p.pos
@@ -441,6 +564,12 @@ func (p *parser) parseTypeName() ast.Type {
 					Value:  p.lit,
 				}
 				p.next()
+			} else if p.tok == lexer.DOLLAR {
+				env := p.parseEnvExpr()
+				if env.Value != nil && env.Value.Tok != lexer.INT {
+					p.error(sizePos, "array size "+env.Value.Value+" is not an integer")
+				}
+				size = env
 			} else {
 				size = p.parseIdent()
 			}
@@ -465,6 +594,18 @@ func (p *parser) parseTypeName() ast.Type {
 				T:       t,
 				Greater: greaterPos,
 			}
+		case lexer.Set:
+			lessPos := p.pos
+			p.expect(lexer.LESS)
+			t := p.parseTypeName()
+			greaterPos := p.pos
+			p.expect(lexer.GREATER)
+			return &ast.SetType{
+				Pos:     pos,
+				Less:    lessPos,
+				T:       t,
+				Greater: greaterPos,
+			}
 		default:
 			return &ast.BasicType{Name: ident}
 		}
@@ -498,6 +639,8 @@ func (p *parser) parseValueSpec(doc *ast.CommentGroup, keyword lexer.Token, iota
 		case lexer.INT, lexer.FLOAT, lexer.STRING:
 			value = &ast.BasicLit{TokPos: p.pos, Tok: p.tok, Value: p.lit}
 			p.next()
+		case lexer.DOLLAR:
+			value = p.parseEnvExpr()
 		default:
 			value = p.parseIdent()
 		}
@@ -533,6 +676,11 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ lexer.Token, _ int) as
 	var path string
 	if p.tok == lexer.STRING {
 		path = p.lit
+		if s, err := p.env.ExpandQuoted(path); err != nil {
+			p.error(pos, err.Error())
+		} else {
+			path = s
+		}
 		if !isValidImport(path) {
 			p.error(pos, "invalid import path: "+path)
 		}
@@ -552,7 +700,13 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ lexer.Token, _ int) as
 	return spec
 }
 
+// ParseFile parses a source file, environment variables are looked up in the OS environment
 func ParseFile(fset *lexer.FileSet, filename string, src []byte) (f *ast.File, err error) {
+	return ParseFileWithEnv(fset, nil, filename, src)
+}
+
+// ParseFileWithEnv parses a source file, environment variables are looked up in env first
+func ParseFileWithEnv(fset *lexer.FileSet, env Env, filename string, src []byte) (f *ast.File, err error) {
 	if len(src) == 0 {
 		src, err = ioutil.ReadFile(filename)
 		if err != nil {
@@ -584,7 +738,7 @@ func ParseFile(fset *lexer.FileSet, filename string, src []byte) (f *ast.File, e
 		}
 		err = p.errors.Err()
 	}()
-	p.init(fset, filename, src)
+	p.init(fset, env, filename, src)
 	f = p.parseFile()
 	if f != nil {
 		f.Filename = filename
@@ -627,7 +781,13 @@ func lookupImportedFiles(importPaths []string, importPkg, suffix string) []strin
 	return nil
 }
 
+// ParseFiles parses source files and imported packages, environment variables are looked up in the OS environment
 func ParseFiles(fset *lexer.FileSet, importPaths, files []string) (map[string]*ast.Package, error) {
+	return ParseFilesWithEnv(fset, nil, importPaths, files)
+}
+
+// ParseFilesWithEnv parses source files and imported packages, environment variables are looked up in env first
+func ParseFilesWithEnv(fset *lexer.FileSet, env Env, importPaths, files []string) (map[string]*ast.Package, error) {
 	if len(files) == 0 {
 		return nil, nil
 	}
@@ -659,7 +819,7 @@ func ParseFiles(fset *lexer.FileSet, importPaths, files []string) (map[string]*a
 			continue
 		}
 		parsed[filename] = true
-		if f, err := ParseFile(fset, filename, nil); err == nil {
+		if f, err := ParseFileWithEnv(fset, env, filename, nil); err == nil {
 			pkg, found := pkgs[pkgId]
 			if !found {
 				pkg = &ast.Package{
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/midlang/mid/src/mid/ast"
@@ -121,6 +122,7 @@ service HelloWorld {
 enum Type {
 	A = 1,
 	B = 2,
+	C,
 }
 `)
 
@@ -136,3 +138,175 @@ enum Type {
 		log.Printf("unresolved ident: %s (pos: %v)", unresolved.Name, fset.Position(unresolved.Pos))
 	}
 }
+
+func TestParseEnv(t *testing.T) {
+	src := []byte(`package demo;
+
+import "${MID_TEST_ROOT}/x";
+
+const (
+	A = $MID_TEST_SIZE;
+	B = ${MID_TEST_UNDEFINED:-hello};
+)
+
+struct User {
+	array<int32,$MID_TEST_SIZE> arr "json:\"$MID_TEST_TAG\"";
+}
+`)
+	env := Env{
+		"MID_TEST_ROOT": "a/b",
+		"MID_TEST_SIZE": "6",
+		"MID_TEST_TAG":  "arr,omitempty",
+	}
+	fset := lexer.NewFileSet()
+	file, err := ParseFileWithEnv(fset, env, "demo.mid", src)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, path := file.Imports[0].Package.IsString(); path != "a/b/x" {
+		t.Errorf("import path: want %q, got %q", "a/b/x", path)
+	}
+	consts := file.Decls[1].(*ast.GenDecl).Specs
+	if lit := consts[0].(*ast.ConstSpec).Value.(*ast.EnvExpr).Value; lit.Tok != lexer.INT || lit.Value != "6" {
+		t.Errorf("const A: want INT 6, got %v %s", lit.Tok, lit.Value)
+	}
+	if lit := consts[1].(*ast.ConstSpec).Value.(*ast.EnvExpr).Value; lit.Tok != lexer.STRING || lit.Value != `"hello"` {
+		t.Errorf("const B: want STRING \"hello\", got %v %s", lit.Tok, lit.Value)
+	}
+	field := file.Decls[2].(*ast.BeanDecl).Fields.List[0]
+	if lit := field.Type.(*ast.ArrayType).Size.(*ast.EnvExpr).Value; lit.Value != "6" {
+		t.Errorf("array size: want 6, got %s", lit.Value)
+	}
+	if want := `"json:\"arr,omitempty\""`; field.Tag.Value != want {
+		t.Errorf("tag: want %s, got %s", want, field.Tag.Value)
+	}
+
+	if _, err := ParseFileWithEnv(fset, nil, "undefined.mid", []byte("package demo;\nconst X = $MID_TEST_UNDEFINED;\n")); err == nil {
+		t.Errorf("undefined environment variable should be an error")
+	}
+}
+
+func TestParseTagEnv(t *testing.T) {
+	src := []byte("package demo;\n" +
+		"struct User {\n" +
+		"\tint64 a `json:\"$MID_TEST_QUOTE\"`;\n" +
+		"\tint64 b \"json:\\\"$MID_TEST_QUOTE\\\"\";\n" +
+		"\tint64 c `json:\"$MID_TEST_BACKQUOTE\"`;\n" +
+		"\tint64 d `re:\"^a$$\" env:\"$$MID_TEST_QUOTE\"`;\n" +
+		"}\n")
+	env := Env{
+		"MID_TEST_QUOTE":     `a"b\c`,
+		"MID_TEST_BACKQUOTE": "a`b",
+	}
+	fset := lexer.NewFileSet()
+	file, err := ParseFileWithEnv(fset, env, "tag.mid", src)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	for i, want := range []string{
+		`json:"a"b\c"`,
+		`json:"a"b\c"`,
+		"json:\"a`b\"",
+		`re:"^a$$" env:"$MID_TEST_QUOTE"`,
+	} {
+		lit := file.Decls[0].(*ast.BeanDecl).Fields.List[i].Tag.Value
+		got, err := strconv.Unquote(lit)
+		if err != nil {
+			t.Errorf("field %d: malformed tag %s: %v", i, lit, err)
+		} else if got != want {
+			t.Errorf("field %d: want tag %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestParseUnion(t *testing.T) {
+	fset := lexer.NewFileSet()
+	file, err := ParseFile(fset, "union.mid", []byte("package demo;\nunion Payload {\n\tint64 id;\n\tstring text;\n}\n"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if decl := file.Decls[0].(*ast.BeanDecl); decl.Kind != lexer.Union || len(decl.Fields.List) != 2 {
+		t.Errorf("want union with 2 variants, got %s with %d fields", decl.Kind, len(decl.Fields.List))
+	}
+
+	for _, src := range []string{
+		"package demo;\nunion Payload {\n\tint64 a, b;\n}\n",
+		"package demo;\nunion Payload {\n\toptional int64 a;\n}\n",
+		"package demo;\nunion Payload extends Base {\n\tint64 a;\n}\n",
+	} {
+		if _, err := ParseFile(fset, "union.mid", []byte(src)); err == nil {
+			t.Errorf("invalid union should be an error: %s", src)
+		}
+	}
+}
+
+func TestParseNestedDecl(t *testing.T) {
+	fset := lexer.NewFileSet()
+	src := "package demo;\nprotocol Login {\n\tenum Platform {\n\t\tIos = 1,\n\t}\n\tstruct Device {\n\t\tstring name;\n\t}\n\tPlatform platform;\n\tDevice device;\n}\nstruct Session {\n\tLogin.Device device;\n}\n"
+	file, err := ParseFile(fset, "nested.mid", []byte(src))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	login := file.Decls[0].(*ast.BeanDecl)
+	if len(login.Decls) != 2 || len(login.Fields.List) != 2 {
+		t.Fatalf("want 2 nested declarations and 2 fields, got %d and %d", len(login.Decls), len(login.Fields.List))
+	}
+	if login.Decls[0].Kind != lexer.ENUM.String() || login.Decls[1].Kind != lexer.STRUCT.String() {
+		t.Errorf("unexpected kinds of nested declarations: %s, %s", login.Decls[0].Kind, login.Decls[1].Kind)
+	}
+	session := file.Decls[1].(*ast.BeanDecl)
+	typ := session.Fields.List[0].Type.(*ast.StructType)
+	if typ.Package.Name != "Login" || typ.Name.Name != "Device" {
+		t.Errorf("want Login.Device, got %s.%s", typ.Package.Name, typ.Name.Name)
+	}
+}
+
+func TestParseSetAndOrderedMap(t *testing.T) {
+	fset := lexer.NewFileSet()
+	file, err := ParseFile(fset, "set.mid", []byte("package demo;\nstruct Bag {\n\tset<string> tags;\n\tomap<string,int64> counts;\n}\n"))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	fields := file.Decls[0].(*ast.BeanDecl).Fields.List
+	if _, ok := fields[0].Type.(*ast.SetType); !ok {
+		t.Errorf("want set type, got %T", fields[0].Type)
+	}
+	if typ, ok := fields[1].Type.(*ast.MapType); !ok || !typ.Ordered {
+		t.Errorf("want ordered map type, got %T", fields[1].Type)
+	}
+}
+
+func TestParseServiceMethods(t *testing.T) {
+	fset := lexer.NewFileSet()
+	src := "package demo;\nservice Chat {\n\tsubscribe(string room) stream Message\n\tupload(stream Chunk chunks) int32\n\tlogin(string user) (string token, int64 expires) throws ErrCode\n\tcheck(int64) throws ErrCode\n}\n"
+	file, err := ParseFile(fset, "service.mid", []byte(src))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	methods := file.Decls[0].(*ast.BeanDecl).Fields.List
+	if len(methods) != 4 {
+		t.Fatalf("want 4 methods, got %d", len(methods))
+	}
+	if ft := methods[0].Type.(*ast.FuncType); !ft.ServerStream || ft.ClientStream || ft.Result == nil {
+		t.Errorf("subscribe should be server streaming")
+	}
+	if ft := methods[1].Type.(*ast.FuncType); !ft.ClientStream || ft.ServerStream {
+		t.Errorf("upload should be client streaming")
+	}
+	if ft := methods[2].Type.(*ast.FuncType); ft.Results == nil || len(ft.Results.List) != 2 || ft.Throws == nil {
+		t.Errorf("login should have 2 results and throw ErrCode")
+	}
+	if ft := methods[3].Type.(*ast.FuncType); ft.Result != nil || ft.Results != nil || ft.Throws == nil {
+		t.Errorf("check should have no result and throw ErrCode")
+	}
+
+	for _, src := range []string{
+		"package demo;\nservice Chat {\n\tupload(int64 id, stream Chunk chunks)\n}\n",
+		"package demo;\nservice Chat {\n\tlogin() (stream string token)\n}\n",
+		"package demo;\nservice Chat {\n\tlogin() (string token, int64)\n}\n",
+	} {
+		if _, err := ParseFile(fset, "service.mid", []byte(src)); err == nil {
+			t.Errorf("invalid method should be an error: %s", src)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package scanner
 
 import (
+	"bytes"
 	"io"
+	"unicode"
 
 	"github.com/midlang/mid/src/mid/external/go/scanner"
 	"github.com/midlang/mid/src/mid/lexer"
@@ -24,7 +26,8 @@ func NewScanner(file *lexer.File, src io.Reader) *Scanner {
 
 func (s *Scanner) Scan() (pos lexer.Pos, tok lexer.Token, lit string) {
 	r := s.Scanner.Scan()
-	pos = lexer.Pos(s.Scanner.Pos().Offset)
+	// offset of the beginning of token
+	pos = lexer.Pos(s.Scanner.Position.Offset)
 	tok = lexer.EOF
 	if r == scanner.EOF {
 		return
@@ -46,9 +49,36 @@ func (s *Scanner) Scan() (pos lexer.Pos, tok lexer.Token, lit string) {
 	default:
 		if op, ok := lexer.LookupOperator(lit); ok {
 			tok = op
+			if tok == lexer.DOLLAR {
+				lit = s.scanEnvRef()
+			}
 		} else {
 			tok = lexer.ILLEGAL
 		}
 	}
 	return
 }
+
+// scanEnvRef scans the rest of an environment variable reference after `$`,
+// i.e. `NAME` or `{NAME:-default}`, and returns the whole reference text.
+func (s *Scanner) scanEnvRef() string {
+	var buf bytes.Buffer
+	buf.WriteByte('$')
+	if s.Peek() == '{' {
+		for {
+			ch := s.Peek()
+			if ch == scanner.EOF || ch == '\n' {
+				break
+			}
+			buf.WriteRune(s.Next())
+			if ch == '}' {
+				break
+			}
+		}
+		return buf.String()
+	}
+	for ch := s.Peek(); ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch); ch = s.Peek() {
+		buf.WriteRune(s.Next())
+	}
+	return buf.String()
+}
@@ -9,9 +9,10 @@ import (
 )
 
 func main() {
+	id := int64(1)
 	info := demo.Info{
 		User: demo.User{
-			Id:         1,
+			Id:         &id,
 			Name:       "user1",
 			OtherNames: []string{"用户1", "ユーザー1"},
 			Code:       [6]byte{1, 2, 3, 4, 5, 6},
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"io"
+)
+
+// Bitmap records presence of optional and required fields, the i-th bit is
+// set if the i-th optional or required field is encoded
+type Bitmap []byte
+
+// NewBitmap creates a bitmap which could hold n bits
+func NewBitmap(n int) Bitmap { return make(Bitmap, (n+7)/8) }
+
+func (b Bitmap) Set(i int)      { b[i/8] |= 1 << uint(i%8) }
+func (b Bitmap) Has(i int) bool { return b[i/8]&(1<<uint(i%8)) != 0 }
+
+// Encode writes bytes of the bitmap, length of bitmap not included
+func (b Bitmap) Encode(w Writer) error {
+	_, err := w.Write(b)
+	return err
+}
+
+// Decode reads len(b) bytes into the bitmap
+func (b Bitmap) Decode(r Reader) error {
+	_, err := io.ReadFull(r, b)
+	return err
+}
+
+// RequiredError is returned if required field is not encoded
+type RequiredError struct {
+	Field string
+}
+
+func (e RequiredError) Error() string {
+	return "required field " + e.Field + " missing"
+}
@@ -0,0 +1,26 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitmap(t *testing.T) {
+	b := NewBitmap(10)
+	assert.Equal(t, 2, len(b))
+	b.Set(0)
+	b.Set(9)
+	assert.True(t, b.Has(0))
+	assert.False(t, b.Has(1))
+	assert.True(t, b.Has(9))
+
+	w := new(bytes.Buffer)
+	assert.Nil(t, b.Encode(w))
+	assert.Equal(t, []byte{0x01, 0x02}, w.Bytes())
+
+	b2 := NewBitmap(10)
+	assert.Nil(t, b2.Decode(w))
+	assert.Equal(t, b, b2)
+}
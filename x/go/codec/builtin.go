@@ -0,0 +1,187 @@
+package codec
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	ErrInvalidUUID    = errors.New("invalid uuid")
+)
+
+// Decimal is an exact decimal number, e.g. amount of money, in canonical text
+// form like "-12.34". Zero value "" represents 0
+type Decimal string
+
+// ParseDecimal parses s as a decimal number, leading `+`, leading zeros of integer
+// part and trailing zeros of fraction part are removed
+func ParseDecimal(s string) (Decimal, error) {
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if s[0] == '-' {
+			sign = "-"
+		}
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return "", ErrInvalidDecimal
+	}
+	for _, part := range [2]string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return "", ErrInvalidDecimal
+			}
+		}
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	fracPart = strings.TrimRight(fracPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if intPart == "0" && fracPart == "" {
+		return "0", nil
+	}
+	if fracPart == "" {
+		return Decimal(sign + intPart), nil
+	}
+	return Decimal(sign + intPart + "." + fracPart), nil
+}
+
+func (d Decimal) String() string {
+	if d == "" {
+		return "0"
+	}
+	return string(d)
+}
+
+// Rat returns value of d as a big.Rat
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// UUID is a 16 bytes universally unique identifier
+type UUID [16]byte
+
+// NewUUID generates a random (version 4) UUID
+func NewUUID() (UUID, error) {
+	var u UUID
+	if _, err := io.ReadFull(rand.Reader, u[:]); err != nil {
+		return u, err
+	}
+	u[6] = (u[6] & 0x0F) | 0x40
+	u[8] = (u[8] & 0x3F) | 0x80
+	return u, nil
+}
+
+// ParseUUID parses UUID in form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalidUUID
+	}
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
+
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Well-known types are encoded by package-level functions on top of Encoder
+// and Decoder, so implementations of the interfaces need not to know them.
+
+// EncodeTime encodes seconds since Unix epoch as a variable-length int64
+// followed by nanoseconds as a variable-length uint32
+func EncodeTime(en Encoder, w Writer, v time.Time) (n int, err error) {
+	n, err = en.EncodeInt64v(w, v.Unix())
+	if err != nil {
+		return
+	}
+	n2, err := en.EncodeUint32v(w, uint32(v.Nanosecond()))
+	n += n2
+	return
+}
+
+// EncodeDuration encodes nanoseconds as a variable-length int64
+func EncodeDuration(en Encoder, w Writer, v time.Duration) (n int, err error) {
+	return en.EncodeInt64v(w, int64(v))
+}
+
+// EncodeDecimal encodes canonical text of decimal as a string
+func EncodeDecimal(en Encoder, w Writer, v Decimal) (n int, err error) {
+	return en.EncodeString(w, v.String())
+}
+
+// EncodeUUID encodes 16 bytes of uuid without length
+func EncodeUUID(en Encoder, w Writer, v UUID) (n int, err error) {
+	return w.Write(v[:])
+}
+
+// DecodeTime decodes time encoded by EncodeTime, the result is in UTC
+func DecodeTime(de Decoder, r Reader) (v time.Time, n int, err error) {
+	var (
+		sec  int64
+		nsec uint32
+		m    int
+	)
+	sec, n, err = de.DecodeInt64v(r)
+	if err != nil {
+		return
+	}
+	nsec, m, err = de.DecodeUint32v(r)
+	n += m
+	if err != nil {
+		return
+	}
+	v = time.Unix(sec, int64(nsec)).UTC()
+	return
+}
+
+// DecodeDuration decodes duration encoded by EncodeDuration
+func DecodeDuration(de Decoder, r Reader) (v time.Duration, n int, err error) {
+	var ns int64
+	ns, n, err = de.DecodeInt64v(r)
+	v = time.Duration(ns)
+	return
+}
+
+// DecodeDecimal decodes decimal encoded by EncodeDecimal
+func DecodeDecimal(de Decoder, r Reader) (v Decimal, n int, err error) {
+	var s string
+	s, n, err = de.DecodeString(r)
+	if err != nil {
+		return
+	}
+	v, err = ParseDecimal(s)
+	return
+}
+
+// DecodeUUID decodes uuid encoded by EncodeUUID
+func DecodeUUID(de Decoder, r Reader) (v UUID, n int, err error) {
+	n, err = io.ReadFull(r, v[:])
+	return
+}
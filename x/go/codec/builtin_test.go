@@ -0,0 +1,88 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeAndDuration(t *testing.T) {
+	w := new(bytes.Buffer)
+	en, de := NewEncoder(), NewDecoder()
+
+	now := time.Date(2024, 2, 29, 12, 30, 45, 123456789, time.UTC)
+	for _, v := range []time.Time{now, time.Unix(0, 0).UTC(), time.Unix(-1, 999).UTC()} {
+		w.Reset()
+		n, err := EncodeTime(en, w, v)
+		assert.Nil(t, err)
+		assert.Equal(t, w.Len(), n)
+		got, m, err := DecodeTime(de, w)
+		assert.Nil(t, err)
+		assert.Equal(t, n, m)
+		assert.True(t, v.Equal(got), "want %v, got %v", v, got)
+	}
+
+	for _, v := range []time.Duration{0, time.Nanosecond, -time.Hour, 36 * time.Hour} {
+		w.Reset()
+		_, err := EncodeDuration(en, w, v)
+		assert.Nil(t, err)
+		got, _, err := DecodeDuration(de, w)
+		assert.Nil(t, err)
+		assert.Equal(t, v, got)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		s, want string
+	}{
+		{"0", "0"},
+		{"-0.00", "0"},
+		{"+012.3400", "12.34"},
+		{"-.5", "-0.5"},
+		{"100", "100"},
+		{"7.", "7"},
+	} {
+		d, err := ParseDecimal(tc.s)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.want, d.String())
+	}
+	for _, s := range []string{"", "-", ".", "1e3", "1.2.3", "abc"} {
+		_, err := ParseDecimal(s)
+		assert.Equal(t, ErrInvalidDecimal, err, "input %q", s)
+	}
+	assert.Equal(t, "0", Decimal("").String())
+	assert.Equal(t, "-1/8", Decimal("-0.125").Rat().String())
+
+	w := new(bytes.Buffer)
+	_, err := EncodeDecimal(NewEncoder(), w, "-12.34")
+	assert.Nil(t, err)
+	got, _, err := DecodeDecimal(NewDecoder(), w)
+	assert.Nil(t, err)
+	assert.Equal(t, Decimal("-12.34"), got)
+}
+
+func TestUUID(t *testing.T) {
+	const s = "123e4567-e89b-12d3-a456-426614174000"
+	u, err := ParseUUID(s)
+	assert.Nil(t, err)
+	assert.Equal(t, s, u.String())
+	for _, invalid := range []string{"", "123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g"} {
+		_, err := ParseUUID(invalid)
+		assert.Equal(t, ErrInvalidUUID, err)
+	}
+
+	random, err := NewUUID()
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0x40), random[6]&0xF0)
+
+	w := new(bytes.Buffer)
+	n, err := EncodeUUID(NewEncoder(), w, u)
+	assert.Nil(t, err)
+	assert.Equal(t, 16, n)
+	got, _, err := DecodeUUID(NewDecoder(), w)
+	assert.Nil(t, err)
+	assert.Equal(t, u, got)
+}
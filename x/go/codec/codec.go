@@ -8,8 +8,9 @@ import (
 const Unused = 0
 
 var (
-	ErrNegativeLength = errors.New("negative length")
-	ErrTooBigLength   = errors.New("too big length")
+	ErrNegativeLength   = errors.New("negative length")
+	ErrTooBigLength     = errors.New("too big length")
+	ErrUnknownUnionKind = errors.New("unknown union kind")
 )
 
 type Dispatcher interface {
@@ -0,0 +1,199 @@
+package presence
+
+import "github.com/midlang/mid/x/go/codec"
+
+var _ = codec.Unused
+
+type Status int
+
+const (
+	Status_Ok  Status = 0
+	Status_Bad Status = 1
+)
+
+type Value interface {
+	UnionKind() int
+}
+
+type Value_Text struct {
+	Text string
+}
+
+func (Value_Text) UnionKind() int { return 1 }
+
+type Value_Number struct {
+	Number int64
+}
+
+func (Value_Number) UnionKind() int { return 2 }
+
+// EncodeValue encodes discriminator of x followed by the variant
+func EncodeValue(w codec.Writer, x Value) error {
+	if x == nil {
+		_, err := codec.Enc.EncodeUint32v(w, 0)
+		return err
+	}
+	if _, err := codec.Enc.EncodeUint32v(w, uint32(x.UnionKind())); err != nil {
+		return err
+	}
+	switch u := x.(type) {
+	case Value_Text:
+		if _, err := codec.Enc.EncodeString(w, u.Text); err != nil {
+			return err
+		}
+
+	case Value_Number:
+		if _, err := codec.Enc.EncodeInt64v(w, u.Number); err != nil {
+			return err
+		}
+
+	default:
+		return codec.ErrUnknownUnionKind
+	}
+	return nil
+}
+
+// DecodeValue decodes union which encoded by EncodeValue into x
+func DecodeValue(r codec.Reader, x *Value) error {
+	kind, _, err := codec.Dec.DecodeUint32v(r)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case 0:
+		*x = nil
+	case 1:
+		var u Value_Text
+		if v, _, err := codec.Dec.DecodeString(r); err != nil {
+			return err
+		} else {
+			u.Text = v
+		}
+
+		*x = u
+	case 2:
+		var u Value_Number
+
+		if v, _, err := codec.Dec.DecodeInt64v(r); err != nil {
+			return err
+		} else {
+			u.Number = v
+		}
+
+		*x = u
+	default:
+		return codec.ErrUnknownUnionKind
+	}
+	return nil
+}
+
+// Required fields must be present on the wire even if they hold zero values
+type Reply struct {
+	Done   bool
+	Count  int32
+	Status Status
+	Note   *string
+	Value  Value
+}
+
+func (x Reply) MessageName() string {
+	return "Reply"
+}
+
+func (x Reply) Encode(w codec.Writer) error {
+
+	presence := codec.NewBitmap(5)
+	presence.Set(0)
+	presence.Set(1)
+	presence.Set(2)
+	if x.Note != nil {
+		presence.Set(3)
+	}
+	if x.Value != nil {
+		presence.Set(4)
+	}
+	if err := presence.Encode(w); err != nil {
+		return err
+	}
+	if _, err := codec.Enc.EncodeBool(w, x.Done); err != nil {
+		return err
+	}
+
+	if _, err := codec.Enc.EncodeInt32v(w, x.Count); err != nil {
+		return err
+	}
+
+	if _, err := codec.Enc.EncodeInt64v(w, int64(x.Status)); err != nil {
+		return err
+	}
+
+	if presence.Has(3) {
+		if _, err := codec.Enc.EncodeString(w, (*x.Note)); err != nil {
+			return err
+		}
+
+	}
+	if presence.Has(4) {
+		if err := EncodeValue(w, x.Value); err != nil {
+			return err
+		}
+
+	}
+	return nil
+}
+
+func (x *Reply) Decode(r codec.Reader) error {
+
+	presence := codec.NewBitmap(5)
+	if err := presence.Decode(r); err != nil {
+		return err
+	}
+	if !presence.Has(0) {
+		return codec.RequiredError{Field: "Reply.done"}
+	}
+	if v, _, err := codec.Dec.DecodeBool(r); err != nil {
+		return err
+	} else {
+		x.Done = v
+	}
+
+	if !presence.Has(1) {
+		return codec.RequiredError{Field: "Reply.count"}
+	}
+
+	if v, _, err := codec.Dec.DecodeInt32v(r); err != nil {
+		return err
+	} else {
+		x.Count = v
+	}
+
+	if !presence.Has(2) {
+		return codec.RequiredError{Field: "Reply.status"}
+	}
+
+	if v, _, err := codec.Dec.DecodeInt64v(r); err != nil {
+		return err
+	} else {
+		x.Status = Status(v)
+	}
+
+	if presence.Has(3) {
+		x.Note = new(string)
+		if v, _, err := codec.Dec.DecodeString(r); err != nil {
+			return err
+		} else {
+			(*x.Note) = v
+		}
+
+	} else {
+		x.Note = nil
+	}
+	if !presence.Has(4) {
+		return codec.RequiredError{Field: "Reply.value"}
+	}
+	if err := DecodeValue(r, &x.Value); err != nil {
+		return err
+	}
+
+	return nil
+}
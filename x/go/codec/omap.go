@@ -0,0 +1,45 @@
+package codec
+
+// OrderedMap is a map which keeps insertion order of keys, zero value is an
+// empty map ready to use
+type OrderedMap[K comparable, V any] struct {
+	keys []K
+	m    map[K]V
+}
+
+// Len returns number of entries
+func (m *OrderedMap[K, V]) Len() int { return len(m.keys) }
+
+// Keys returns keys in insertion order, the returned slice must not be modified
+func (m *OrderedMap[K, V]) Keys() []K { return m.keys }
+
+// Get returns value of key k
+func (m *OrderedMap[K, V]) Get(k K) (V, bool) {
+	v, ok := m.m[k]
+	return v, ok
+}
+
+// Set sets value of key k, order of an existing key is not changed
+func (m *OrderedMap[K, V]) Set(k K, v V) {
+	if m.m == nil {
+		m.m = make(map[K]V)
+	}
+	if _, ok := m.m[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.m[k] = v
+}
+
+// Delete removes key k
+func (m *OrderedMap[K, V]) Delete(k K) {
+	if _, ok := m.m[k]; !ok {
+		return
+	}
+	delete(m.m, k)
+	for i, key := range m.keys {
+		if key == k {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap[string, int]
+	assert.Equal(t, 0, m.Len())
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+
+	m.Set("c", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	m.Set("c", 4)
+	assert.Equal(t, []string{"c", "a", "b"}, m.Keys())
+	v, ok := m.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+
+	m.Delete("a")
+	m.Delete("x")
+	assert.Equal(t, []string{"c", "b"}, m.Keys())
+	assert.Equal(t, 2, m.Len())
+}
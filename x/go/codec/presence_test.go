@@ -0,0 +1,35 @@
+package codec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/midlang/mid/x/go/codec"
+	"github.com/midlang/mid/x/go/codec/internal/generated/presence"
+)
+
+func TestRequiredZeroValues(t *testing.T) {
+	// false, 0 and enum member Ok = 0 are values of required fields
+	x := presence.Reply{Value: presence.Value_Number{}}
+	w := new(bytes.Buffer)
+	assert.Nil(t, x.Encode(w))
+	var y presence.Reply
+	assert.Nil(t, y.Decode(w))
+	assert.Equal(t, x, y)
+	assert.Equal(t, presence.Status_Ok, y.Status)
+}
+
+func TestRequiredMissing(t *testing.T) {
+	// nil union is not encoded
+	w := new(bytes.Buffer)
+	assert.Nil(t, presence.Reply{Done: true}.Encode(w))
+	var x presence.Reply
+	assert.Equal(t, codec.RequiredError{Field: "Reply.value"}, x.Decode(w))
+
+	// bit of Reply.done is not set
+	w.Reset()
+	w.WriteByte(0)
+	assert.Equal(t, codec.RequiredError{Field: "Reply.done"}, x.Decode(w))
+}